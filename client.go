@@ -2,13 +2,14 @@ package main
 
 import (
 	"bufio"
-	"context"
-	"encoding/csv"
-	"errors"
+	"crypto/ed25519"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -16,8 +17,6 @@ import (
 	"time"
 
 	"github.com/GoooGu/liarslie/liars_network"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type ModeType int64
@@ -25,281 +24,1390 @@ type ModeType int64
 const (
 	STANDARD ModeType = iota
 	EXPERT
+	// The agents agree on the network value among themselves through PBFT.
+	BFT
 )
 
+var mode_names = map[string]ModeType{
+	"standard": STANDARD,
+	"expert":   EXPERT,
+	"bft":      BFT,
+}
+
+// The commands available in every mode, in the order they are listed to the user.
+var mode_commands = map[ModeType][]string{
+	STANDARD: {"start", "play", "stop"},
+	EXPERT:   {"extend", "playexpert", "topology", "kill"},
+	BFT:      {"start", "play", "kill", "stop"},
+}
+
+func (mode ModeType) String() string {
+	for name, other_mode := range mode_names {
+		if other_mode == mode {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// Where the client writes its messages. With -output json, the messages of every command are collected
+// and written along with its result instead.
+var output io.Writer = os.Stdout
+
+// Where the result of every command is written with -output json.
+var result_output io.Writer = os.Stdout
+
+// The metrics of the client, which are served along with those of its agents with -metrics-addr.
+var (
+	client_games_total = liars_network.DefaultMetrics.NewCounter("liarslie_client_games_total",
+		"The play and playexpert commands the client ran, by mode.", "mode")
+	client_decisions_total = liars_network.DefaultMetrics.NewCounter("liarslie_client_decisions_total",
+		"The games in which the client decided a network value, by mode.", "mode")
+	client_failures_total = liars_network.DefaultMetrics.NewCounter("liarslie_client_failures_total",
+		"The commands which failed, by command.", "command")
+)
+
+// The outcome of a single command, written as one JSON object per command with -output json.
+type CommandResult struct {
+	Command string `json:"command"`
+	Ok      bool   `json:"ok"`
+	// The agents launched by start or extend.
+	Agents []liars_network.AgentRecord `json:"agents,omitempty"`
+	// The agent removed by kill.
+	Killed *liars_network.AgentRecord `json:"killed,omitempty"`
+	// The proxy agent which answered playexpert.
+	Proxy string `json:"proxy,omitempty"`
+	// The values the network value was decided from.
+	Responses []int32 `json:"responses,omitempty"`
+	// The agents which timed out, could not be reached or whose values were forged.
+	Abstentions []string `json:"abstentions,omitempty"`
+	// The agents whose values were forged, or were reported by a proxy agent whose report is inconsistent.
+	Forged []string `json:"forged,omitempty"`
+	// Whether the report of the proxy agent matched the spot-checked agents, if any were spot-checked.
+	Consistent *bool           `json:"consistent,omitempty"`
+	Decision   *DecisionResult `json:"decision,omitempty"`
+	// The outcomes of the trials run by simulate.
+	Simulation *liars_network.SimulationResult `json:"simulation,omitempty"`
+	// The table written by sweep.
+	Sweep []liars_network.SweepRow `json:"sweep,omitempty"`
+	// The messages printed while the command ran, which describe why it failed if it did.
+	Messages []string `json:"messages,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+	// The agents the command launched and the responses it gathered, which are recorded in the transcript.
+	transcript_entries []liars_network.TranscriptEntry
+}
+
+type DecisionResult struct {
+	Decided    bool    `json:"decided"`
+	Value      int32   `json:"value"`
+	Confidence float64 `json:"confidence"`
+	// The view of the PBFT protocol the value was agreed on in bft mode.
+	View *int32 `json:"view,omitempty"`
+}
+
+// Collects the lines written to it, from any goroutine, until they are flushed.
+type MessageCollector struct {
+	mutex   sync.Mutex
+	pending string
+	lines   []string
+}
+
+func (collector *MessageCollector) Write(message []byte) (int, error) {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	collector.pending += string(message)
+	for {
+		end := strings.IndexByte(collector.pending, '\n')
+		if end < 0 {
+			return len(message), nil
+		}
+		collector.lines = append(collector.lines, collector.pending[:end])
+		collector.pending = collector.pending[end+1:]
+	}
+}
+
+// Returns the lines collected so far, including any unterminated one, and forgets them.
+func (collector *MessageCollector) Flush() []string {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	lines := collector.lines
+	if collector.pending != "" {
+		lines = append(lines, collector.pending)
+	}
+	collector.lines, collector.pending = nil, ""
+	return lines
+}
+
+// What the client knows about the network from the most recent start/extend command.
+type NetworkState struct {
+	honest_agents_num int
+	liar_ratio        float64
+	max_value         int32
+	// The most recent topology command, or nil if the agents have no neighbors to gossip with.
+	topology_command *liars_network.TopologyCommand
+}
+
+// The command line flags which affect how agents are launched.
+type LaunchOptions struct {
+	// Launches every new agent as a separate OS process rather than a goroutine.
+	is_process_mode bool
+	// Records whether every agent is honest in agents.config.
+	is_audit_mode bool
+	// The host every new agent binds to.
+	host string
+	// Issues the certificate of every new agent, or nil if agents do not use TLS.
+	certificate_authority *liars_network.CertificateAuthority
+	// The directory of the local CA, which is set up once agents use TLS.
+	tls_dir string
+	// The ports every new agent is launched over in order, or nil to launch it over any free port.
+	port_range *liars_network.PortRange
+	// The address every new agent process serves its metrics over, or "" if it does not. Agents running
+	// as goroutines are counted in the metrics of the client instead.
+	metrics_address string
+}
+
+// The command line flags which affect how agents are queried.
+type QueryOptions struct {
+	// The max number of agents queried at the same time.
+	workers_num int
+	// How long to wait for the response of a single agent.
+	timeout time.Duration
+}
+
 func main() {
+	// `liarslie agent ...` runs a single agent in this process instead of the client.
+	if len(os.Args) > 1 && os.Args[1] == "agent" {
+		liars_network.RunAgentProcess(os.Args[2:])
+		return
+	}
 	mode_flag := flag.String("mode", "standard", "The mode in which the user wants to play.")
+	process_flag := flag.Bool("process", false, "Launches every agent as a separate OS process instead of a goroutine.")
+	audit_flag := flag.Bool("audit", false, "Records whether every agent is honest in agents.config.")
+	host_flag := flag.String("host", "localhost", "The host every agent binds to and is reached through.")
+	workers_flag := flag.Int("workers", 64, "The max number of agents play queries at the same time.")
+	timeout_flag := flag.Duration("timeout", 5*time.Second, "How long play waits for the response of a single agent. "+
+		"A proxy agent waits as long for every agent it queries in playexpert, or for every hop it gossips through.")
+	tls_flag := flag.Bool("tls", false, "Requires mTLS between the client and the agents, and between the agents themselves.")
+	tls_dir_flag := flag.String("tls-dir", "tls", "The directory of the local CA and of the certificates it issues with -tls.")
+	script_flag := flag.String("script", "", "Runs the commands in this file instead of reading them from the standard input.")
+	output_flag := flag.String("output", "text", "Either text, or json to write the result of every command as one JSON object per line.")
+	seed_flag := flag.Int64("seed", 0, "Seeds which agents lie, the values they pick and which agents are sampled, to replay a game. "+
+		"Defaults to the current time.")
+	ports_flag := flag.String("ports", "", "Launches the agents over the free ports of this range in order, e.g. 9000-9099, "+
+		"rather than over any free port.")
+	transcript_flag := flag.String("transcript", "", "Appends every command, the values of the agents it launched, the responses it "+
+		"gathered and its result to this file.")
+	metrics_address_flag := flag.String("metrics-addr", "", "Serves the metrics of the client and its agents in the Prometheus "+
+		"text format over http://address/metrics, e.g. localhost:9090. With -process, every agent process serves its own metrics "+
+		"over the next available port of -host instead, which it keeps when its value is updated and is recorded in agents.config.")
 	flag.Parse()
-	var curr_mode ModeType = STANDARD
-	// Makes sure the mode can only be standard or expert
-	if *mode_flag != "standard" && *mode_flag != "expert" {
-		fmt.Println("Please select either standard or expert mode.")
-	}
-	if *mode_flag == "expert" {
-		curr_mode = EXPERT
-	}
-	launched_agents_list := []*liars_network.Agent{}
-	var honest_agents_num int
-	rand.Seed(time.Now().UnixNano())
-	scanner := bufio.NewScanner(os.Stdin)
-command_reader_loop:
-	for {
-		if scanner.Scan() {
-			command := scanner.Text()
-			switch strings.Split(command, " ")[0] {
-			case "start":
-				if curr_mode != STANDARD {
-					fmt.Println("Please only enter the available commands in expert mode: extend, playexpert & kill.")
-					continue
-				}
-				if !LaunchAgents(&launched_agents_list, &honest_agents_num, command, curr_mode) {
-					continue
-				}
-			case "play":
-				if curr_mode != STANDARD {
-					fmt.Println("Please only enter the available commands in expert mode: extend, playexpert & kill.")
-					continue
-				}
-				if len(launched_agents_list) == 0 {
-					fmt.Println("Please make sure you enter the start command first before you play.")
-					continue
-				}
-				PlayCommand(honest_agents_num)
+	launch_options := LaunchOptions{is_process_mode: *process_flag, is_audit_mode: *audit_flag, host: *host_flag, tls_dir: *tls_dir_flag}
+	if *metrics_address_flag != "" {
+		metrics_address, err := liars_network.DefaultMetrics.Serve(*metrics_address_flag)
+		if err != nil {
+			log.Fatalf("Failed to serve the metrics: %s", err)
+		}
+		// The output is not set up yet, and with -output json, only the results of the commands go to stdout.
+		fmt.Fprintln(os.Stderr, "Serving the metrics over http://"+metrics_address+"/metrics")
+		launch_options.metrics_address = net.JoinHostPort(*host_flag, "0")
+	}
+	if *ports_flag != "" {
+		var err error
+		if launch_options.port_range, err = liars_network.ParsePortRange(*ports_flag); err != nil {
+			log.Fatalf("Invalid -ports: %s", err)
+		}
+	}
+	if *tls_flag {
+		launch_options.certificate_authority = SetUpTLS(*tls_dir_flag)
+	}
+	if *workers_flag < 1 {
+		log.Fatalln("-workers must be >= 1.")
+	}
+	query_options := QueryOptions{workers_num: *workers_flag, timeout: *timeout_flag}
+	// Makes sure the mode can only be standard, expert or bft
+	curr_mode, valid := mode_names[*mode_flag]
+	if !valid {
+		fmt.Fprintln(output, "Please select either standard, expert or bft mode.")
+	}
+	// In expert mode, agents.config may already record agents from an earlier extend, which are kept.
+	// In standard mode, start always creates a new agents.config.
+	registry := liars_network.NewRegistry("agents.config")
+	if curr_mode == EXPERT {
+		var err error
+		if registry, err = liars_network.LoadRegistry("agents.config"); err != nil {
+			log.Fatalf("Failed to read agents.config: %s", err)
+		}
+	}
+	// Every agent draws from its own generator, seeded from this one as it is launched, so that the same
+	// seed replays the same game no matter how the agents are scheduled.
+	is_flag_set := map[string]bool{}
+	flag.Visit(func(set_flag *flag.Flag) { is_flag_set[set_flag.Name] = true })
+	if !is_flag_set["seed"] {
+		*seed_flag = time.Now().UnixNano()
+	}
+	rand.Seed(*seed_flag)
+	// Commands are read from the script if one is given, or typed in otherwise.
+	input := os.Stdin
+	if *script_flag != "" {
+		var err error
+		if input, err = os.Open(*script_flag); err != nil {
+			log.Fatalf("Failed to open the script: %s", err)
+		}
+		defer input.Close()
+	}
+	client_state := &ClientState{curr_mode: curr_mode, registry: registry, launch_options: launch_options,
+		query_options: query_options}
+	if *transcript_flag != "" {
+		var err error
+		if client_state.transcript, err = liars_network.OpenTranscript(*transcript_flag); err != nil {
+			log.Fatalf("Failed to open the transcript: %s", err)
+		}
+		client_state.session = &liars_network.TranscriptSession{Seed: *seed_flag, Mode: curr_mode.String(), Ports: *ports_flag,
+			Process: *process_flag, TLS: *tls_flag, Host: *host_flag}
+		if err := client_state.transcript.Record(liars_network.TranscriptEntry{Type: liars_network.SESSION_ENTRY,
+			Session: client_state.session}); err != nil {
+			log.Fatalf("Failed to write the transcript: %s", err)
+		}
+	}
+	switch *output_flag {
+	case "text":
+	case "json":
+		// The messages of the agents launched in this process, or as child processes, are collected too.
+		client_state.collector = new(MessageCollector)
+		output = client_state.collector
+		liars_network.SetOutput(client_state.collector)
+	default:
+		log.Fatalln("-output must be either text or json.")
+	}
+	is_successful := RunCommands(client_state, input)
+	if client_state.transcript != nil {
+		client_state.transcript.Close()
+	}
+	if !is_successful {
+		os.Exit(1)
+	}
+}
 
-			case "stop":
-				if curr_mode != STANDARD {
-					fmt.Println("Please only enter the available commands in expert mode: extend, playexpert & kill.")
-					continue
-				}
-				for _, agent := range launched_agents_list {
-					agent.Stop()
-				}
-				fmt.Println("Deleting agents.config...")
-				e := os.Remove("agents.config")
-				if e != nil {
-					log.Fatalln("Failed to delete agents.config: ", e)
-				}
-				break command_reader_loop
+// Everything the commands of a client share.
+type ClientState struct {
+	curr_mode      ModeType
+	registry       *liars_network.Registry
+	network_state  NetworkState
+	launch_options LaunchOptions
+	query_options  QueryOptions
+	// Collects the messages of every command with -output json, or nil with -output text.
+	collector *MessageCollector
+	// Where every command is recorded, or nil if no transcript is kept.
+	transcript *liars_network.Transcript
+	// How the session recorded to the transcript was started, or nil if no transcript is kept.
+	session *liars_network.TranscriptSession
+	// The values the agents launched by the next start/extend are assigned instead of new ones, while a
+	// transcript is replayed.
+	replayed_values []int32
+}
 
-			case "extend":
-				if curr_mode != EXPERT {
-					fmt.Println("Please only enter the available commands in standard mode: start, play & stop.")
-					continue
-				}
-				if !LaunchAgents(&launched_agents_list, &honest_agents_num, command, curr_mode) {
-					continue
-				}
+// Executes every command read from input, one per line, until the stop command or the end of input.
+// Blank lines and lines starting with # are skipped. Returns false if any command failed.
+func RunCommands(client_state *ClientState, input io.Reader) bool {
+	is_successful := true
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		command := strings.TrimSpace(scanner.Text())
+		if command == "" || strings.HasPrefix(command, "#") {
+			continue
+		}
+		result := new(CommandResult)
+		is_command_successful, is_stopped := ExecuteCommand(client_state, command, result)
+		result.Ok = is_command_successful
+		WriteResult(client_state, result)
+		RecordCommand(client_state, command, result)
+		CountCommand(client_state.curr_mode, result)
+		is_successful = is_successful && is_command_successful
+		if is_stopped {
+			return is_successful
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(output, "Failed to read the commands:", err)
+		is_successful = false
+	}
+	// Agent processes would otherwise be left running with no client to play with or stop them, and agents
+	// running as goroutines would die along with the client while agents.config still records them, so the
+	// end of input stops them the same as stop.
+	if len(client_state.registry.Agents()) != 0 {
+		StopAgents(client_state.registry, client_state.launch_options)
+		result := &CommandResult{Command: "stop", Ok: true}
+		WriteResult(client_state, result)
+		RecordCommand(client_state, "stop", result)
+	}
+	return is_successful
+}
 
-			case "playexpert":
-				if curr_mode != EXPERT {
-					fmt.Println("Please only enter the available commands in standard mode: start, play & stop.")
-					continue
-				}
-				if !PlayExpertCommand(honest_agents_num, launched_agents_list, command) {
-					continue
-				}
+// Writes result along with the messages collected while the command ran as one JSON object. Does
+// nothing with -output text, as the messages have already been printed.
+func WriteResult(client_state *ClientState, result *CommandResult) {
+	if client_state.collector == nil {
+		return
+	}
+	if result.Ok {
+		result.Messages = client_state.collector.Flush()
+	} else {
+		result.Errors = client_state.collector.Flush()
+	}
+	if err := json.NewEncoder(result_output).Encode(result); err != nil {
+		log.Fatalln("Failed to write the result of the command: ", err)
+	}
+}
 
-			case "kill":
-				if curr_mode != EXPERT {
-					fmt.Println("Please only enter the available commands in standard mode: start, play & stop.")
-					continue
-				}
-				id, valid := liars_network.CheckKillCommand(command)
-				// In case of an invalid kill command
-				if !valid {
-					continue
-				}
-				var is_agent_found bool = false
-				// Linearly search for an agent whose port number matches the input id
-				for i, agent := range launched_agents_list {
-					if agent.IsMatchingPortNumber(id) {
-						// Removes the agents from the list by swapping the agent about to be removed with
-						// the agent at the end of the list and truncating the list to original_size - 1.
-						original_size := len(launched_agents_list)
-						launched_agents_list[i] = launched_agents_list[original_size-1]
-						launched_agents_list = launched_agents_list[:original_size-1]
-						agent.Stop()
-						is_agent_found = true
-						break
-					}
+// Appends command to the transcript, followed by the agents it launched, the responses it gathered and
+// result. Does nothing if no transcript is kept.
+func RecordCommand(client_state *ClientState, command string, result *CommandResult) {
+	if client_state.transcript == nil {
+		return
+	}
+	encoded_result, err := json.Marshal(result)
+	if err != nil {
+		log.Fatalln("Failed to encode the result of the command: ", err)
+	}
+	entries := append([]liars_network.TranscriptEntry{{Type: liars_network.COMMAND_ENTRY, Command: command}}, result.transcript_entries...)
+	entries = append(entries, liars_network.TranscriptEntry{Type: liars_network.RESULT_ENTRY, Result: encoded_result})
+	for _, entry := range entries {
+		if err := client_state.transcript.Record(entry); err != nil {
+			log.Fatalln("Failed to write the transcript: ", err)
+		}
+	}
+}
+
+// Counts the games played and the commands failed in the metrics of the client.
+func CountCommand(curr_mode ModeType, result *CommandResult) {
+	if result.Command == "play" || result.Command == "playexpert" {
+		client_games_total.Inc(curr_mode.String())
+		if result.Decision != nil && result.Decision.Decided {
+			client_decisions_total.Inc(curr_mode.String())
+		}
+	}
+	if !result.Ok {
+		client_failures_total.Inc(result.Command)
+	}
+}
+
+// Returns the name of command, which is its first token, or "" if it has no tokens. The name is
+// tokenized the same as the arguments of the command.
+func CommandName(command string) (string, error) {
+	tokens, err := liars_network.Tokenize(command)
+	if err != nil || len(tokens) == 0 {
+		return "", err
+	}
+	return tokens[0].Text, nil
+}
+
+// Executes a single command, filling result in. Returns whether the command succeeded and whether it
+// stopped the client.
+func ExecuteCommand(client_state *ClientState, command string, result *CommandResult) (bool, bool) {
+	registry, network_state := client_state.registry, &client_state.network_state
+	curr_mode, launch_options, query_options := client_state.curr_mode, client_state.launch_options, client_state.query_options
+	command_name, err := CommandName(command)
+	if err != nil {
+		fmt.Fprintln(output, err)
+		return false, false
+	}
+	result.Command = command_name
+	switch command_name {
+	case "start", "play", "stop", "extend", "topology", "playexpert", "kill":
+		if !CheckModeCommand(curr_mode, command_name) {
+			return false, false
+		}
+	}
+	switch command_name {
+	case "mode":
+		return SwitchMode(client_state, command), false
+
+	case "replay":
+		return ReplayTranscript(client_state, command), false
+
+	case "simulate":
+		return SimulateCommand(command, result), false
+
+	case "sweep":
+		return SweepCommand(client_state, command, result), false
+
+	case "start":
+		return LaunchAgents(registry, network_state, command, curr_mode, launch_options, result, client_state.replayed_values), false
+
+	case "play":
+		if len(registry.Agents()) == 0 {
+			fmt.Fprintln(output, "Please make sure you enter the start command first before you play.")
+			return false, false
+		}
+		if curr_mode == BFT {
+			return PlayBftCommand(registry, command, query_options, result), false
+		}
+		return PlayCommand(*network_state, registry, command, query_options, result), false
+
+	case "stop":
+		StopAgents(registry, launch_options)
+		return true, true
+
+	case "extend":
+		if !LaunchAgents(registry, network_state, command, curr_mode, launch_options, result, client_state.replayed_values) {
+			return false, false
+		}
+		// The new agents join the topology, which is rebuilt over the whole network.
+		if network_state.topology_command != nil {
+			return ApplyTopology(registry, network_state.topology_command, query_options), false
+		}
+		return true, false
+
+	case "topology":
+		if len(registry.Agents()) == 0 {
+			fmt.Fprintln(output, "Please make sure you enter the extend command first before you set up a topology.")
+			return false, false
+		}
+		topology_command, err := liars_network.ParseTopologyCommand(command, len(registry.Records()))
+		if err != nil {
+			fmt.Fprintln(output, err)
+			fmt.Fprintln(output, "Please enter the topology command following the convention of:\n"+
+				"topology --shape ring|regular|smallworld [--degree k] [--rewire p]")
+			return false, false
+		}
+		if !ApplyTopology(registry, topology_command, query_options) {
+			return false, false
+		}
+		network_state.topology_command = topology_command
+		return true, false
+
+	case "playexpert":
+		public_keys := liars_network.PublicKeysByAddress(registry.Records())
+		return PlayExpertCommand(*network_state, registry.Agents(), public_keys, command, query_options, result), false
+
+	case "kill":
+		kill_command, err := liars_network.ParseKillCommand(command)
+		// In case of an invalid kill command
+		if err != nil {
+			fmt.Fprintln(output, err)
+			fmt.Fprintln(output, "Please enter the kill command following the convention of:\nkill --id host:port|port")
+			return false, false
+		}
+		address, err := registry.ResolveAddress(kill_command.Id)
+		if err != nil {
+			fmt.Fprintln(output, "Fails to find a matching agent:", err)
+			return false, false
+		}
+		// Removes the agent reached through the address from the registry, which also removes it from
+		// agents.config, and then kills it.
+		for _, record := range registry.Records() {
+			if record.Address() == address {
+				killed_record := record
+				result.Killed = &killed_record
+			}
+		}
+		agent, is_removed, err := registry.Remove(address)
+		if err != nil {
+			log.Fatalf("Failed to write agents.config: %s", err)
+		}
+		if !is_removed {
+			fmt.Fprintln(output, "Fails to find a matching agent whose address is ", address)
+			result.Killed = nil
+			return false, false
+		}
+		if agent == nil {
+			// The agent was launched by another client, which is the only one that can stop it.
+			fmt.Fprintln(output, "Removed the record of the agent", address, "which was launched by another client, but cannot stop it.")
+		} else {
+			agent.Kill()
+			RemoveAgentCertificate(launch_options, address)
+		}
+		// The topology is rebuilt over the remaining agents, so that none of them gossips with the killed one.
+		if network_state.topology_command != nil {
+			return RebuildTopology(registry, network_state, query_options), false
+		}
+		return true, false
+
+	default:
+		fmt.Fprintln(output, "Cannot recognize command:", command)
+		return false, false
+	}
+}
+
+// Handles mode command, which switches the client to another mode while keeping the running agents,
+// so that both query styles can be compared on the same agents.
+func SwitchMode(client_state *ClientState, command string) bool {
+	tokens, err := liars_network.Tokenize(command)
+	if err != nil {
+		fmt.Fprintln(output, err)
+	}
+	if err != nil || len(tokens) != 2 {
+		fmt.Fprintln(output, "Please enter the mode command following the convention of:\nmode standard|expert|bft")
+		return false
+	}
+	next_mode, valid := mode_names[tokens[1].Text]
+	if !valid {
+		fmt.Fprintln(output, "Please select either standard, expert or bft mode.")
+		return false
+	}
+	records := client_state.registry.Records()
+	ReconcileNetworkState(&client_state.network_state, records)
+	fmt.Fprintf(output, "Switched from %s to %s mode with %d running agents, %d of which are assumed to be honest.\n",
+		client_state.curr_mode, next_mode, len(records), client_state.network_state.honest_agents_num)
+	client_state.curr_mode = next_mode
+	return true
+}
+
+// Handles replay command, which rebuilds the network recorded in a session of a transcript, with every
+// agent assigned its recorded value, and runs the recorded commands again with the recorded seed.
+// Reports every command whose outcome differs from the recorded one. The replay is self-contained: the
+// agents it leaves running are stopped, and the client carries on in the mode and with the launch
+// settings it had before.
+func ReplayTranscript(client_state *ClientState, command string) bool {
+	replay_command, err := liars_network.ParseReplayCommand(command)
+	if err != nil {
+		fmt.Fprintln(output, err)
+		fmt.Fprintln(output, "Please enter the replay command following the convention of:\nreplay --file path [--session n]")
+		return false
+	}
+	if len(client_state.registry.Agents()) != 0 {
+		fmt.Fprintln(output, "Please stop the running agents before replay, which rebuilds the network from scratch.")
+		return false
+	}
+	entries, err := liars_network.ReadTranscript(replay_command.File)
+	if err != nil {
+		fmt.Fprintln(output, "Failed to read the transcript:", err)
+		return false
+	}
+	sessions := liars_network.TranscriptSessions(entries)
+	session_index := replay_command.Session - 1
+	if replay_command.Session == 0 {
+		session_index = len(sessions) - 1
+	}
+	if session_index < 0 || session_index >= len(sessions) {
+		fmt.Fprintln(output, "The transcript records", len(sessions), "sessions, so there is no session", replay_command.Session, "to replay.")
+		return false
+	}
+	session := sessions[session_index]
+	recorded_mode, valid := mode_names[session[0].Session.Mode]
+	if !valid {
+		fmt.Fprintln(output, "The transcript records an unknown mode:", session[0].Session.Mode)
+		return false
+	}
+	port_range := client_state.launch_options.port_range
+	if session[0].Session.Ports != "" {
+		if port_range, err = liars_network.ParsePortRange(session[0].Session.Ports); err != nil {
+			fmt.Fprintln(output, "The transcript records an invalid range of ports:", err)
+			return false
+		}
+	}
+	saved_mode, saved_launch_options, saved_network_state := client_state.curr_mode, client_state.launch_options,
+		client_state.network_state
+	client_state.launch_options.port_range = port_range
+	// The agents are launched the same way as when the session was recorded. The sessions of transcripts
+	// which predate recording how, which have no host, are launched according to the flags of this client.
+	if recorded_session := session[0].Session; recorded_session.Host != "" {
+		launch_options := &client_state.launch_options
+		launch_options.is_process_mode, launch_options.host = recorded_session.Process, recorded_session.Host
+		if recorded_session.TLS && launch_options.certificate_authority == nil {
+			launch_options.certificate_authority = SetUpTLS(launch_options.tls_dir)
+		} else if !recorded_session.TLS && launch_options.certificate_authority != nil {
+			launch_options.certificate_authority = nil
+			liars_network.SetDialConfig(nil)
+		}
+	}
+	client_state.curr_mode = recorded_mode
+	client_state.network_state = NetworkState{}
+	rand.Seed(session[0].Session.Seed)
+	// The replayed commands are recorded in the transcript kept now as a session of their own, so that
+	// the transcript can be replayed in turn.
+	if client_state.transcript != nil {
+		if err := client_state.transcript.Record(liars_network.TranscriptEntry{Type: liars_network.SESSION_ENTRY,
+			Session: session[0].Session}); err != nil {
+			log.Fatalln("Failed to write the transcript: ", err)
+		}
+	}
+
+	// The ids of the recorded agents, both their addresses and their bare port numbers, mapped to the
+	// addresses of the agents replacing them.
+	replayed_addresses := map[string]string{}
+	replayed_commands_num, mismatches_num := 0, 0
+	for i, entry := range session {
+		if command_name, _ := CommandName(entry.Command); entry.Type != liars_network.COMMAND_ENTRY || command_name == "replay" {
+			continue
+		}
+		// Every entry up to the next command belongs to this command.
+		var recorded_values []int32
+		var recorded_result *CommandResult
+		for _, command_entry := range session[i+1:] {
+			if command_entry.Type == liars_network.COMMAND_ENTRY {
+				break
+			}
+			if command_entry.Type == liars_network.AGENT_ENTRY {
+				recorded_values = append(recorded_values, command_entry.Agent.Value)
+			}
+			if command_entry.Type == liars_network.RESULT_ENTRY {
+				recorded_result = new(CommandResult)
+				if err := json.Unmarshal(command_entry.Result, recorded_result); err != nil {
+					fmt.Fprintln(output, "Ignoring the invalid result recorded for", entry.Command, ":", err)
+					recorded_result = nil
 				}
-				if !is_agent_found {
-					fmt.Println("Fails to find a matching agent whose id/port_number is ", id)
+			}
+		}
+		replayed_command := entry.Command
+		if kill_command, err := liars_network.ParseKillCommand(entry.Command); err == nil {
+			if address, exists := replayed_addresses[kill_command.Id]; exists {
+				replayed_command = "kill --id " + address
+			}
+		}
+		fmt.Fprintln(output, "Replaying:", replayed_command)
+		result := new(CommandResult)
+		client_state.replayed_values = recorded_values
+		is_command_successful, is_stopped := ExecuteCommand(client_state, replayed_command, result)
+		client_state.replayed_values = nil
+		result.Ok = is_command_successful
+		RecordCommand(client_state, replayed_command, result)
+		replayed_commands_num++
+		if recorded_result != nil {
+			for j, record := range recorded_result.Agents {
+				if j < len(result.Agents) {
+					replayed_addresses[record.Address()] = result.Agents[j].Address()
+					replayed_addresses[strconv.Itoa(record.Port)] = result.Agents[j].Address()
 				}
-			default:
-				fmt.Println("Cannot recognize command:", scanner.Text())
 			}
+			if DescribeOutcome(recorded_result) != DescribeOutcome(result) {
+				mismatches_num++
+				fmt.Fprintf(output, "Mismatch: %q %s, but it %s when recorded.\n", entry.Command, DescribeOutcome(result),
+					DescribeOutcome(recorded_result))
+			}
+		}
+		if is_stopped {
+			break
+		}
+	}
+	fmt.Fprintln(output, "Replayed", replayed_commands_num, "commands,", mismatches_num, "of which had a different outcome than recorded.")
+	RestoreAfterReplay(client_state, saved_mode, saved_launch_options, saved_network_state)
+	return true
+}
+
+// Stops the agents left running by a replay, and restores the mode, the launch settings and the state
+// of the network the client had before the replay changed them.
+func RestoreAfterReplay(client_state *ClientState, curr_mode ModeType, launch_options LaunchOptions, network_state NetworkState) {
+	if len(client_state.registry.Agents()) != 0 {
+		fmt.Fprintln(output, "Stopping the agents left running by the replay.")
+		StopAgents(client_state.registry, client_state.launch_options)
+	}
+	if client_state.launch_options.certificate_authority != launch_options.certificate_authority {
+		if launch_options.certificate_authority == nil {
+			liars_network.SetDialConfig(nil)
+		} else {
+			launch_options.certificate_authority = SetUpTLS(launch_options.tls_dir)
+		}
+	}
+	client_state.curr_mode, client_state.launch_options, client_state.network_state = curr_mode, launch_options, network_state
+	fmt.Fprintln(output, "Restored", curr_mode, "mode and the launch settings from before the replay.")
+	// The commands from here on are recorded in a session of their own, started the same as the session
+	// before the replay, but with a seed drawn after the replay reseeded the generator.
+	if client_state.transcript != nil && client_state.session != nil {
+		restored_session := *client_state.session
+		restored_session.Seed = rand.Int63()
+		restored_session.Mode = curr_mode.String()
+		rand.Seed(restored_session.Seed)
+		if err := client_state.transcript.Record(liars_network.TranscriptEntry{Type: liars_network.SESSION_ENTRY,
+			Session: &restored_session}); err != nil {
+			log.Fatalln("Failed to write the transcript: ", err)
+		}
+	}
+}
+
+// Describes whether a command succeeded and the value it decided, if any, which a replay compares.
+func DescribeOutcome(result *CommandResult) string {
+	switch {
+	case !result.Ok:
+		return "failed"
+	case result.Decision == nil:
+		return "succeeded"
+	case !result.Decision.Decided:
+		return "did not decide a value"
+	default:
+		return fmt.Sprintf("decided %d", result.Decision.Value)
+	}
+}
+
+// Handles simulate command, which runs many start/play cycles in this process without launching any
+// agent, and reports how often the network value is recovered.
+func SimulateCommand(command string, result *CommandResult) bool {
+	simulate_command, err := liars_network.ParseSimulateCommand(command)
+	if err != nil {
+		fmt.Fprintln(output, err)
+		fmt.Fprintln(output, "Please enter the simulate command following the convention of:\n"+
+			"simulate --trials n --num-agents number --liar-ratio ratio --max-value max [--value v] "+
+			"[--strategy constant|random|collude] [--coalition shared|split] [--decider exact|plurality|supermajority|bayesian] "+
+			"[--threshold t]")
+		return false
+	}
+	simulation_result, err := liars_network.Simulate(simulate_command.SimulationParameters, simulate_command.TrialsNum)
+	if err != nil {
+		fmt.Fprintln(output, "Failed to simulate:", err)
+		return false
+	}
+	result.Simulation = &simulation_result
+	fmt.Fprintf(output, "Recovered the network value in %d out of %d trials (%.2f%%). %d trials ended in a tie and %d decided a wrong value.\n",
+		simulation_result.RecoveredNum, simulation_result.TrialsNum, 100*simulation_result.SuccessRate(), simulation_result.TiesNum,
+		simulation_result.WrongNum)
+	return true
+}
+
+// Handles sweep command, which simulates every pair of a liar ratio and a max value of its grids, and
+// writes a table of how often the network value is recovered at each of them. With -output json and no
+// file, the rows are only written as part of the result.
+func SweepCommand(client_state *ClientState, command string, result *CommandResult) bool {
+	sweep_command, err := liars_network.ParseSweepCommand(command)
+	if err != nil {
+		fmt.Fprintln(output, err)
+		fmt.Fprintln(output, "Please enter the sweep command following the convention of:\n"+
+			"sweep --trials n --num-agents number --liar-ratios grid --max-values grid [--value v] "+
+			"[--strategy constant|random|collude] [--coalition shared|split] [--decider exact|plurality|supermajority|bayesian] "+
+			"[--threshold t] [--format csv|json] [--file path]\n"+
+			"where a grid is either a list such as 0.1,0.2,0.4 or a range such as 0:0.5:0.05")
+		return false
+	}
+	rows, err := liars_network.Sweep(sweep_command.SimulationParameters, sweep_command.LiarRatios, sweep_command.MaxValues,
+		sweep_command.TrialsNum)
+	if err != nil {
+		fmt.Fprintln(output, "Failed to sweep:", err)
+		return false
+	}
+	result.Sweep = rows
+	if sweep_command.File == "" && client_state.collector != nil {
+		return true
+	}
+	if sweep_command.File == "" {
+		return liars_network.WriteSweepTable(output, rows, sweep_command.Format) == nil
+	}
+	file, err := os.Create(sweep_command.File)
+	if err == nil {
+		err = liars_network.WriteSweepTable(file, rows, sweep_command.Format)
+		if close_err := file.Close(); err == nil {
+			err = close_err
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(output, "Failed to write the table:", err)
+		return false
+	}
+	fmt.Fprintln(output, "Wrote", len(rows), "rows to", sweep_command.File)
+	return true
+}
+
+// Recounts the honest agents among the running agents, which changes as agents are killed, or which is
+// unknown if the agents were launched by another client. The roles recorded with -audit are counted if
+// every agent has one. Otherwise, the liar ratio of the most recent start/extend is assumed.
+func ReconcileNetworkState(network_state *NetworkState, records []liars_network.AgentRecord) {
+	audited_honest_agents_num := 0
+	for _, record := range records {
+		if record.Role == "" {
+			network_state.honest_agents_num = len(records) - int(network_state.liar_ratio*float64(len(records)))
+			return
+		}
+		if record.Role == liars_network.HONEST_ROLE {
+			audited_honest_agents_num++
+		}
+	}
+	network_state.honest_agents_num = audited_honest_agents_num
+}
+
+// Stops every agent launched by this client and removes its record. agents.config is deleted once it
+// records no agent at all.
+func StopAgents(registry *liars_network.Registry, launch_options LaunchOptions) {
+	for _, agent := range registry.Agents() {
+		agent.Stop()
+		if _, _, err := registry.Remove(agent.RetrieveAddress()); err != nil {
+			log.Fatalln("Failed to write agents.config: ", err)
+		}
+		RemoveAgentCertificate(launch_options, agent.RetrieveAddress())
+	}
+	// The agents launched by other clients are kept in agents.config, as only those clients can stop them.
+	if len(registry.Records()) != 0 {
+		return
+	}
+	fmt.Fprintln(output, "Deleting agents.config...")
+	if err := registry.Clear(); err != nil {
+		log.Fatalln("Failed to delete agents.config: ", err)
+	}
+}
+
+// Deletes the certificate of the agent reached through address once it left the network, if the agents use TLS.
+func RemoveAgentCertificate(launch_options LaunchOptions, address string) {
+	if launch_options.certificate_authority == nil {
+		return
+	}
+	if err := launch_options.certificate_authority.RemoveAgentCertificate(address); err != nil {
+		fmt.Fprintln(output, "Failed to delete the certificate of the agent", address, ":", err)
+	}
+}
+
+// Loads the local CA in tls_dir, or creates it if there is none yet, and has the client authenticate
+// with a certificate issued by it from now on. Returns the CA, which issues the certificates of the agents.
+func SetUpTLS(tls_dir string) *liars_network.CertificateAuthority {
+	certificate_authority, err := liars_network.LoadOrCreateCertificateAuthority(tls_dir)
+	if err != nil {
+		log.Fatalf("Failed to set up the CA in %s: %s", tls_dir, err)
+	}
+	client_files, err := certificate_authority.IssueClientCertificate()
+	if err != nil {
+		log.Fatalf("Failed to issue the certificate of the client: %s", err)
+	}
+	dial_config, err := liars_network.NewDialConfig(client_files)
+	if err != nil {
+		log.Fatalf("Failed to load the certificate of the client: %s", err)
+	}
+	liars_network.SetDialConfig(dial_config)
+	return certificate_authority
+}
+
+// Checks whether command_name is available in curr_mode. If not, lists the commands which are.
+func CheckModeCommand(curr_mode ModeType, command_name string) bool {
+	available_commands := mode_commands[curr_mode]
+	for _, available_command := range available_commands {
+		if available_command == command_name {
+			return true
 		}
 	}
+	last := len(available_commands) - 1
+	fmt.Fprintf(output, "Please only enter the available commands in %s mode: %s & %s.\n", curr_mode,
+		strings.Join(available_commands[:last], ", "), available_commands[last])
+	return false
 }
 
-// Handles both extend and start command.
-func LaunchAgents(launched_agents_list *[]*liars_network.Agent, honest_agents_num *int, command string, curr_mode ModeType) bool {
-	if curr_mode == STANDARD && len(*launched_agents_list) != 0 {
-		fmt.Println("The start command has already been run. You cannot rerun it.")
+// Handles both extend and start command. If replayed_values is not nil, the agents are assigned these
+// values rather than new ones.
+func LaunchAgents(registry *liars_network.Registry, network_state *NetworkState, command string, curr_mode ModeType,
+	launch_options LaunchOptions, result *CommandResult, replayed_values []int32) bool {
+	existing_agents := registry.Agents()
+	if curr_mode != EXPERT && len(existing_agents) != 0 {
+		fmt.Fprintln(output, "The start command has already been run. You cannot rerun it.")
 		return false
 	}
-	flags_map := liars_network.CheckStartOrExtendCommand(command)
-	if flags_map == nil {
-		if curr_mode == STANDARD {
-			fmt.Println("Please enter the start command following the convention of:\n" +
-				"start --value v --max-value max --num-agents number --liar-ratio ratio")
+	var start_command *liars_network.StartCommand
+	var err error
+	if curr_mode != EXPERT {
+		start_command, err = liars_network.ParseStartCommand(command)
+	} else {
+		var extend_command *liars_network.ExtendCommand
+		extend_command, err = liars_network.ParseExtendCommand(command)
+		start_command = (*liars_network.StartCommand)(extend_command)
+	}
+	if err != nil {
+		fmt.Fprintln(output, err)
+		if curr_mode != EXPERT {
+			fmt.Fprintln(output, "Please enter the start command following the convention of:\n"+
+				"start --value v --max-value max --num-agents number --liar-ratio ratio [--strategy constant|random|collude|flip] "+
+				"[--coalition shared|split] [--tamper p]")
 		} else {
-			fmt.Println("Please enter the extend command following the convention of:\n" +
-				"extend --value v --max-value max --num-agents number --liar-ratio ratio")
+			fmt.Fprintln(output, "Please enter the extend command following the convention of:\n"+
+				"extend --value v --max-value max --num-agents number --liar-ratio ratio [--strategy constant|random|collude|flip] "+
+				"[--coalition shared|split] [--tamper p]")
 		}
 		return false
 	}
-	network_value := int32(flags_map["value"])
-	max_value := int32(flags_map["max_value"])
-	new_agents_num := int(flags_map["num_agents"])
-	liar_ratio := flags_map["liar_ratio"]
+	network_value, max_value := start_command.NetworkValue, start_command.MaxValue
+	new_agents_num, liar_ratio := start_command.AgentsNum, start_command.LiarRatio
+	// The strategy defaults to CONSTANT_LIE when it is not specified.
+	strategy_type, coalition_type := start_command.Strategy, start_command.Coalition
+	// Only liars tamper with the values they relay as proxy agents.
+	tamper_probability := start_command.TamperProbability
 
-	// If called from start, then len(launched_agents_list) is always 0.
-	// If called from extend, then len(launched_agents_list) could be 0 or non-zero.
-	total_num_agents := len(*launched_agents_list) + new_agents_num
+	// If called from start, then len(existing_agents) is always 0.
+	// If called from extend, then len(existing_agents) could be 0 or non-zero.
+	total_num_agents := len(existing_agents) + new_agents_num
 	liar_agents_num := int(liar_ratio * float64(total_num_agents))
-	*honest_agents_num = total_num_agents - liar_agents_num
 
-	// If in expert mode and agents.config does not exist, then creates a new agents.config file. If in standard mode,
-	// always creates a new agents.config.
-	var agents_config *os.File
-	if _, err := os.Stat("agents.config"); curr_mode == EXPERT && errors.Is(err, os.ErrNotExist) || curr_mode == STANDARD {
-		agents_config, err = os.Create("agents.config")
-		if err != nil {
-			log.Fatalf("Failed to create agents.config %s", err)
-		}
+	// The first liar_agents_num agents are assigned an arbitrary value, while the rest of them
+	// are assigned the input network_value. Colluding liars pick their values deterministically.
+	var agent_values []int32
+	if coalition_type == liars_network.NO_COALITION {
+		agent_values = liars_network.AssignAgentValues(strategy_type, network_value, max_value, total_num_agents, liar_agents_num)
 	} else {
-		agents_config, err = os.OpenFile("agents.config", os.O_APPEND|os.O_WRONLY, 0644)
-		if err != nil {
-			log.Fatalf("Failed to read agent.config: %s", err)
-		}
-	}
-	defer agents_config.Close()
-	config_writer := csv.NewWriter(agents_config)
-	for i := 0; i < total_num_agents; i++ {
-		// Initializes agent_value to be the input network_value; updates it according to
-		// how many liar_agents we have upped - if the number of liar_agents upped is below
-		// the threshold, then modifies agent_value to an arbitrary number. Otherwise, keeps
-		// it unchanged.
-		var agent_value int32 = network_value
-		if i < liar_agents_num {
-			var arbitrary_value int32 = 1 + rand.Int31n(max_value)
-			// Makes sure there is no collision between network value and arbitrary value.
-			for arbitrary_value == int32(network_value) {
-				arbitrary_value = 1 + rand.Int31n(max_value)
-			}
-			agent_value = arbitrary_value
+		var err error
+		if agent_values, err = liars_network.AssignCoalitionValues(coalition_type, network_value, max_value,
+			total_num_agents, liar_agents_num); err != nil {
+			fmt.Fprintln(output, err)
+			return false
 		}
+	}
+	if replayed_values != nil {
+		if len(replayed_values) == len(agent_values) {
+			agent_values = replayed_values
+		} else {
+			fmt.Fprintln(output, "Warning: the transcript records", len(replayed_values), "agent values rather than", len(agent_values),
+				"so new values are assigned instead.")
+		}
+	}
+	network_state.honest_agents_num = total_num_agents - liar_agents_num
+	network_state.liar_ratio, network_state.max_value = liar_ratio, max_value
+
+	is_every_agent_ready := true
+	for i, agent_value := range agent_values {
+		agent_tamper_probability := tamper_probability
+		role := liars_network.LIAR_ROLE
+		if agent_value == network_value {
+			agent_tamper_probability = 0
+			role = liars_network.HONEST_ROLE
+		}
+		transcript_agent := &liars_network.TranscriptAgent{Value: agent_value, Role: role, Strategy: strategy_type.String(),
+			TamperProbability: agent_tamper_probability}
 		// Creates a new agent
 		if i < new_agents_num {
-			var wait_group sync.WaitGroup
-			wait_group.Add(1)
-			port_number := make(chan int)
-			new_agent := new(liars_network.Agent)
-			*launched_agents_list = append(*launched_agents_list, new_agent)
-			go new_agent.Init(port_number, agent_value, &wait_group)
-			config_writer.Write([]string{strconv.FormatInt(int64(<-port_number), 10)})
-			config_writer.Flush()
-			wait_group.Wait()
+			var new_agent liars_network.LaunchedAgent
+			agent_seed := rand.Int63()
+			listen_port := 0
+			if launch_options.port_range != nil {
+				var err error
+				if listen_port, err = launch_options.port_range.Allocate(launch_options.host); err != nil {
+					log.Fatalf("Failed to allocate the port of the agent: %s", err)
+				}
+			}
+			var tls_files *liars_network.TLSFiles
+			if launch_options.certificate_authority != nil {
+				// The certificate is bound to the port of the agent, which therefore has to be known up front.
+				var err error
+				if listen_port == 0 {
+					if listen_port, err = liars_network.AllocateFreePort(launch_options.host); err != nil {
+						log.Fatalf("Failed to allocate the port of the agent: %s", err)
+					}
+				}
+				if tls_files, err = launch_options.certificate_authority.IssueAgentCertificate(launch_options.host, listen_port); err != nil {
+					log.Fatalf("Failed to issue the certificate of the agent: %s", err)
+				}
+			}
+			if launch_options.is_process_mode {
+				executable, err := os.Executable()
+				if err != nil {
+					log.Fatalf("Failed to locate the executable: %s", err)
+				}
+				new_agent, err = liars_network.LaunchAgentProcess(executable, launch_options.host, listen_port, strategy_type, agent_value,
+					network_value, max_value, agent_tamper_probability, tls_files, agent_seed, launch_options.metrics_address)
+				if err != nil {
+					log.Fatalf("Failed to launch agent process: %s", err)
+				}
+			} else {
+				var wait_group sync.WaitGroup
+				wait_group.Add(1)
+				port_number := make(chan int)
+				random := liars_network.NewRandom(agent_seed)
+				strategy := liars_network.NewStrategy(strategy_type, agent_value, network_value, max_value, random)
+				agent := new(liars_network.Agent)
+				agent.SetRandom(random)
+				agent.SetTamperProbability(agent_tamper_probability)
+				if tls_files != nil {
+					server_credentials, err := liars_network.NewServerCredentials(tls_files)
+					if err != nil {
+						log.Fatalf("Failed to load the certificate of the agent: %s", err)
+					}
+					agent.SetServerCredentials(server_credentials)
+				}
+				go agent.Init(port_number, net.JoinHostPort(launch_options.host, strconv.Itoa(listen_port)), strategy, &wait_group)
+				<-port_number
+				wait_group.Wait()
+				new_agent = agent
+			}
+			host, port, _ := net.SplitHostPort(new_agent.RetrieveAddress())
+			port_number, _ := strconv.Atoi(port)
+			record := &liars_network.AgentRecord{Host: host, Port: port_number, Pid: new_agent.RetrievePid(), LaunchTime: time.Now(),
+				PublicKey: liars_network.EncodePublicKey(new_agent.RetrievePublicKey()), MetricsAddress: new_agent.RetrieveMetricsAddress()}
+			if launch_options.is_audit_mode {
+				record.Audit(agent_value, network_value)
+			}
+			if err := registry.Add(new_agent, record); err != nil {
+				log.Fatalf("Failed to write agents.config: %s", err)
+			}
+			result.Agents = append(result.Agents, *record)
+			transcript_agent.Address = new_agent.RetrieveAddress()
 		} else {
 			// This condition should only be entered in EXPERT Mode. For the already launched agents,
 			// updates their values to reflect the newly added agents and the input from the extend
 			// command.
-			fmt.Println("Existing agent ", i-new_agents_num, " updating its value...")
-			(*launched_agents_list)[i-new_agents_num].UpdateValue(agent_value)
+			fmt.Fprintln(output, "Existing agent ", i-new_agents_num, " updating its value...")
+			existing_agent := existing_agents[i-new_agents_num]
+			if err := existing_agent.Reassign(strategy_type, agent_value, network_value, max_value, agent_tamper_probability); err != nil {
+				// The agent is no longer running, so it leaves the network.
+				fmt.Fprintln(output, "Removing the agent", existing_agent.RetrieveAddress(), "which failed to update its value:", err)
+				if _, _, err := registry.Remove(existing_agent.RetrieveAddress()); err != nil {
+					log.Fatalf("Failed to write agents.config: %s", err)
+				}
+				RemoveAgentCertificate(launch_options, existing_agent.RetrieveAddress())
+				if agent_value == network_value {
+					network_state.honest_agents_num--
+				}
+				is_every_agent_ready = false
+				continue
+			}
+			err := registry.Update(existing_agent, func(record *liars_network.AgentRecord) {
+				// An agent process is relaunched with a new pid and key pair when its value is updated.
+				record.Pid = existing_agent.RetrievePid()
+				record.PublicKey = liars_network.EncodePublicKey(existing_agent.RetrievePublicKey())
+				record.MetricsAddress = existing_agent.RetrieveMetricsAddress()
+				if launch_options.is_audit_mode {
+					record.Audit(agent_value, network_value)
+				}
+			})
+			if err != nil {
+				log.Fatalf("Failed to write agents.config: %s", err)
+			}
+			transcript_agent.Address, transcript_agent.IsReassigned = existing_agent.RetrieveAddress(), true
 		}
+		result.transcript_entries = append(result.transcript_entries,
+			liars_network.TranscriptEntry{Type: liars_network.AGENT_ENTRY, Agent: transcript_agent})
 	}
-	fmt.Println("Ready")
+	if !is_every_agent_ready {
+		return false
+	}
+	fmt.Fprintln(output, "Ready")
 	return true
 }
 
-// Handles play command in standard mode
-func PlayCommand(honest_agents_num int) {
-	// Tries to find the agents.config file and reads from it
-	agents_config, err := os.Open("agents.config")
+// Connects the agents in the registry according to the flags of a topology command, by telling every
+// agent its neighbors.
+func ApplyTopology(registry *liars_network.Registry, topology_command *liars_network.TopologyCommand, query_options QueryOptions) bool {
+	records := registry.Records()
+	topology_type, degree := topology_command.Shape, topology_command.Degree
+	topology, err := liars_network.BuildTopology(topology_type, len(records), degree, topology_command.RewireProbability)
 	if err != nil {
-		log.Fatalf("Failed to open agents.config: %s", err)
+		fmt.Fprintln(output, err)
+		return false
+	}
+	is_connected := true
+	for i, adjacent := range topology {
+		var neighbors []string
+		for _, j := range adjacent {
+			neighbors = append(neighbors, records[j].Address())
+		}
+		if err := liars_network.SetAgentNeighbors(records[i].Address(), neighbors, query_options.timeout); err != nil {
+			fmt.Fprintln(output, "Failed to set the neighbors of agent", records[i].Address(), ":", err)
+			is_connected = false
+		}
+	}
+	fmt.Fprintln(output, "Connected", len(records), "agents in a", topology_type, "topology of degree", degree)
+	return is_connected
+}
+
+// Rebuilds the topology of the network after an agent left it. If the remaining agents are too few for
+// the topology, they are disconnected from one another instead, and the topology is dropped.
+func RebuildTopology(registry *liars_network.Registry, network_state *NetworkState, query_options QueryOptions) bool {
+	records := registry.Records()
+	topology_command := network_state.topology_command
+	_, err := liars_network.BuildTopology(topology_command.Shape, len(records), topology_command.Degree,
+		topology_command.RewireProbability)
+	if err == nil {
+		return ApplyTopology(registry, topology_command, query_options)
+	}
+	fmt.Fprintln(output, "The remaining agents no longer fit the topology, so they are disconnected from one another:", err)
+	network_state.topology_command = nil
+	is_disconnected := true
+	for _, record := range records {
+		if err := liars_network.SetAgentNeighbors(record.Address(), nil, query_options.timeout); err != nil {
+			fmt.Fprintln(output, "Failed to set the neighbors of agent", record.Address(), ":", err)
+			is_disconnected = false
+		}
 	}
-	agents_port_nums_list, err := csv.NewReader(agents_config).ReadAll()
+	return is_disconnected
+}
+
+// Handles play command in standard mode
+func PlayCommand(network_state NetworkState, registry *liars_network.Registry, command string, query_options QueryOptions,
+	result *CommandResult) bool {
+	play_command, err := liars_network.ParsePlayCommand(command)
 	if err != nil {
-		log.Fatalf("Failed to read agent.config: %s", err)
+		fmt.Fprintln(output, err)
+		fmt.Fprintln(output, "Please enter the play command following the convention of:\n"+
+			"play [--decider exact|plurality|supermajority|bayesian] [--threshold t]")
+		return false
+	}
+	// Queries every agent recorded in agents.config
+	var addresses []string
+	for _, record := range registry.Records() {
+		addresses = append(addresses, record.Address())
 	}
 	responses := []int32{}
-	// Retrieves grpc responses from each client and then collects all the responses
-	for _, row := range agents_port_nums_list {
-		conn, err := grpc.Dial(":"+row[0], grpc.WithTransportCredentials(insecure.NewCredentials()))
-
-		if err != nil {
-			log.Fatalf("could not connect: %s", err)
+	var timed_out_addresses []string
+	var unreachable_addresses []string
+	// Retrieves grpc responses from all the agents concurrently and then collects all the responses. Agents
+	// which time out or cannot be reached abstain.
+	for _, query_result := range liars_network.QueryAgents(addresses, new(liars_network.LieRequest), query_options.workers_num,
+		query_options.timeout) {
+		result.transcript_entries = append(result.transcript_entries,
+			liars_network.NewResponseEntry(query_result.Address, query_result.Response, query_result.Err))
+		if query_result.IsTimedOut() {
+			timed_out_addresses = append(timed_out_addresses, query_result.Address)
+			continue
 		}
-		defer conn.Close()
-		client := liars_network.NewLieServiceClient(conn)
-		response, err := client.LieQuery(context.Background(), new(liars_network.LieRequest))
-		if err != nil {
-			log.Fatalf("Error when calling LieQuery: %s", err)
+		if query_result.Err != nil {
+			unreachable_addresses = append(unreachable_addresses, query_result.Address)
+			continue
 		}
-		responses = append(responses, response.AgentValue)
+		responses = append(responses, query_result.Response.AgentValue)
+	}
+	if len(timed_out_addresses) != 0 {
+		fmt.Fprintln(output, "Timed out waiting for", len(timed_out_addresses), "agents:", strings.Join(timed_out_addresses, ", "))
+	}
+	if len(unreachable_addresses) != 0 {
+		fmt.Fprintln(output, "Failed to reach", len(unreachable_addresses), "agents:", strings.Join(unreachable_addresses, ", "))
+	}
+	abstentions_num := len(timed_out_addresses) + len(unreachable_addresses)
+	if abstentions_num != 0 {
+		fmt.Fprintln(output, abstentions_num, "out of", len(addresses), "agents abstained.")
+	}
+	// By default, the network value is found by finding the unique element from the slice which matches the
+	// same frequncy, which is the number of honest agents in the network minus the honest agents which may have
+	// abstained. If there are more than one value whose frequency matches, then a correct network value cannot
+	// be decided.
+	decider := SelectDecider(play_command.DeciderFlags, liars_network.NetworkAssumptions{HonestAgentsNum: network_state.honest_agents_num,
+		AbstentionsNum: abstentions_num, LiarRatio: network_state.liar_ratio, MaxValue: network_state.max_value})
+	result.Responses = responses
+	result.Abstentions = append(timed_out_addresses, unreachable_addresses...)
+	PrintDecision(decider.Decide(responses), result)
+	return true
+}
+
+// Handles play command in bft mode. A random agent coordinates a run of the PBFT protocol among all the
+// agents, which agree on the network value as long as less than a third of them are liars.
+func PlayBftCommand(registry *liars_network.Registry, command string, query_options QueryOptions, result *CommandResult) bool {
+	if err := liars_network.ParseCommand(command, nil); err != nil {
+		fmt.Fprintln(output, err)
+		fmt.Fprintln(output, "Please enter the play command following the convention of:\nplay")
+		return false
+	}
+	var replicas []string
+	for _, record := range registry.Records() {
+		replicas = append(replicas, record.Address())
 	}
-	// The network value is found by finding the unique element from the slice which matches the same frequncy,
-	// which is the number of honest agents in the network. If there are more than one value whose frequency
-	// matches the number of honest agents, then a correct network value cannot be decided.
-	if network_value, exists := liars_network.FindNetworkValue(responses, honest_agents_num); exists {
-		fmt.Println("The network value is ", network_value)
+	// The liars are the first agents launched, so the replicas are shuffled to not always have them
+	// lead the first views.
+	rand.Shuffle(len(replicas), func(i, j int) { replicas[i], replicas[j] = replicas[j], replicas[i] })
+	coordinator := replicas[rand.Intn(len(replicas))]
+	response, err := liars_network.AskAgreement(coordinator, replicas,
+		query_options.timeout+liars_network.AgreeTimeout(len(replicas)))
+	if err != nil {
+		fmt.Fprintln(output, "Failed to ask the agent", coordinator, "for the agreed value:", err)
+		return false
+	}
+	if !response.Committed {
+		fmt.Fprintln(output, "The network value cannot be decided because the agents failed to agree on any value.")
+		result.Decision = &DecisionResult{}
+		return true
+	}
+	result.Decision = &DecisionResult{Decided: true, Value: response.Value, Confidence: 1, View: &response.View}
+	fmt.Fprintf(output, "The network value is  %d (agreed on by the agents in view %d)\n", response.Value, response.View)
+	return true
+}
+
+// Creates the decider selected by the --decider and --threshold flags.
+func SelectDecider(decider_flags liars_network.DeciderFlags, assumptions liars_network.NetworkAssumptions) liars_network.Decider {
+	assumptions.Threshold = decider_flags.Threshold
+	// The decider defaults to EXACT_DECIDER when it is not specified.
+	return liars_network.NewDecider(decider_flags.Decider, assumptions)
+}
+
+// Prints decision, which is also recorded in result.
+func PrintDecision(decision liars_network.Decision, result *CommandResult) {
+	result.Decision = &DecisionResult{Decided: decision.Decided, Value: decision.Value, Confidence: decision.Confidence}
+	if decision.Decided {
+		fmt.Fprintf(output, "The network value is  %d (confidence %.3f)\n", decision.Value, decision.Confidence)
 	} else {
-		fmt.Println("The network value cannot be decided because the liar agents successfully fooled the client.")
+		fmt.Fprintln(output, "The network value cannot be decided because the liar agents successfully fooled the client.")
 	}
 }
 
-func PlayExpertCommand(honest_agents_num int, launched_agents_list []*liars_network.Agent, command string) bool {
+// Handles playexpert command. public_keys maps the address of every agent to the key which verifies its
+// signatures.
+func PlayExpertCommand(network_state NetworkState, launched_agents_list []liars_network.LaunchedAgent,
+	public_keys map[string]ed25519.PublicKey, command string, query_options QueryOptions, result *CommandResult) bool {
 	if len(launched_agents_list) == 0 {
-		fmt.Println("Please make sure you enter the extend command first before you playexpert.")
+		fmt.Fprintln(output, "Please make sure you enter the extend command first before you playexpert.")
 		return false
 	}
-	flag_map := liars_network.CheckPlayExpertCommand(command, int64(len(launched_agents_list)))
-	if flag_map == nil {
-		fmt.Println("Please enter the playexpert command following the convention of:\n" + "playexpert --num-agents number --liar-ratio ratio")
+	playexpert_command, err := liars_network.ParsePlayExpertCommand(command, len(launched_agents_list))
+	if err != nil {
+		fmt.Fprintln(output, err)
+		fmt.Fprintln(output, "Please enter the playexpert command following the convention of:\n"+
+			"playexpert --num-agents number --liar-ratio ratio [--decider exact|plurality|supermajority|bayesian] [--threshold t] "+
+			"[--hops h] [--verify k]")
 		return false
 	}
-	liar_ratio := flag_map["liar_ratio"]
+	num_agents, liar_ratio := playexpert_command.AgentsNum, playexpert_command.LiarRatio
+	// Without --hops, the proxy agent contacts the other sampled agents directly. Otherwise, it gossips
+	// through its neighbors, and the sampled agents which are too many hops away abstain.
+	hops := int32(playexpert_command.Hops)
+	if hops != 0 && network_state.topology_command == nil {
+		fmt.Fprintln(output, "Warning: no topology has been set up, so the proxy agent has no neighbors to gossip with.")
+	}
 	// The frequency of the network value based on the assumption given from the user.
 	assumed_frequency := len(launched_agents_list) - int(liar_ratio*float64(len(launched_agents_list)))
-	if assumed_frequency != honest_agents_num {
-		fmt.Println("Warning: the input of liar_ratio in playexpert differs from that of the most recent extend.")
+	if assumed_frequency != network_state.honest_agents_num {
+		fmt.Fprintln(output, "Warning: the input of liar_ratio in playexpert differs from that of the most recent extend.")
 	}
-	proxy_agent := launched_agents_list[0]
-
-	// The port numbers of all the agents which the proxy agent communicates with.
-	var other_agent_ids []string
-	for _, other_agent := range launched_agents_list[1:] {
-		other_agent_ids = append(other_agent_ids, other_agent.RetrievePortNum())
+	// Picks num_agents agents at random from the network. The first one of them acts as the proxy
+	// agent, which collects the values from the rest of the sampled agents.
+	var sampled_agent_ids []string
+	for _, index := range rand.Perm(len(launched_agents_list))[:num_agents] {
+		sampled_agent_ids = append(sampled_agent_ids, launched_agents_list[index].RetrieveAddress())
 	}
 
-	// Establishes the connection with the proxy agent.
-	conn, err := grpc.Dial(":"+proxy_agent.RetrievePortNum(), grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		log.Fatalf("could not connect: %s", err)
+	// Queries the proxy agent. If the proxy agent cannot be reached, it abstains and the next sampled
+	// agent becomes the proxy agent instead.
+	var response *liars_network.LieResponse
+	var unreachable_agent_ids []string
+	// Every agent signs its value along with the nonce, so that the proxy agent can neither forge nor replay them.
+	nonce := liars_network.NewNonce()
+	for len(sampled_agent_ids) != 0 {
+		proxy_agent_id, other_agent_ids := sampled_agent_ids[0], sampled_agent_ids[1:]
+		proxy_timeout := query_options.timeout + liars_network.ProxyFanOutTimeout(len(other_agent_ids), query_options.timeout)
+		if hops != 0 {
+			proxy_timeout = query_options.timeout + liars_network.GossipTimeout(int(hops), query_options.timeout)
+		}
+		query_result := liars_network.QueryAgent(proxy_agent_id,
+			&liars_network.LieRequest{ExpertMode: true, OtherAgentIds: other_agent_ids, Hops: hops, Nonce: nonce,
+				QueryTimeoutMs: query_options.timeout.Milliseconds()}, proxy_timeout)
+		result.transcript_entries = append(result.transcript_entries,
+			liars_network.NewResponseEntry(query_result.Address, query_result.Response, query_result.Err))
+		if query_result.Err == nil {
+			response = query_result.Response
+			break
+		}
+		fmt.Fprintln(output, "Failed to query the proxy agent", proxy_agent_id, "and picking another one:", query_result.Err)
+		unreachable_agent_ids = append(unreachable_agent_ids, proxy_agent_id)
+		sampled_agent_ids = other_agent_ids
+	}
+	if response == nil {
+		fmt.Fprintln(output, "None of the sampled agents could be reached.")
+		return false
+	}
+	// The loop above stops with the proxy agent which responded at the head of sampled_agent_ids.
+	proxy_agent_id := sampled_agent_ids[0]
+	result.Proxy = proxy_agent_id
+	unreachable_agent_ids = append(unreachable_agent_ids, response.GetUnreachableAgentIds()...)
+	if len(unreachable_agent_ids) != 0 {
+		fmt.Fprintln(output, len(unreachable_agent_ids), "out of", num_agents, "agents abstained:", strings.Join(unreachable_agent_ids, ", "))
+	}
+	for _, record := range response.GetGossipRecords() {
+		fmt.Fprintln(output, "Agent", record.AgentId, "answered", record.Value, "through", strings.Join(record.Path, " -> "))
+	}
+	if playexpert_command.VerifiedAgentsNum != 0 {
+		is_consistent := VerifyProxyReport(proxy_agent_id, sampled_agent_ids[1:], response, playexpert_command.VerifiedAgentsNum,
+			nonce, public_keys)
+		result.Consistent = &is_consistent
 	}
-	defer conn.Close()
 
-	// Queries the proxy agent
-	client := liars_network.NewLieServiceClient(conn)
-	response, err := client.LieQuery(context.Background(), &liars_network.LieRequest{ExpertMode: true, OtherAgentIds: other_agent_ids})
+	// The decision is only made from the relayed values which are signed by the agents which answered
+	// them, as the proxy agent may have tampered with the rest. The agents whose values are missing or
+	// fail to verify abstain. A report which does not stick to the requested agents is discarded whole.
+	all_values_from_network, forged_agent_ids, err := liars_network.VerifySignedValues(response.GetSignedValues(),
+		sampled_agent_ids[1:], response.GetUnreachableAgentIds(), nonce, public_keys)
 	if err != nil {
-		log.Fatalf("Error when calling LieQuery: %s", err)
+		fmt.Fprintln(output, "Warning: discarded the report of the proxy agent", proxy_agent_id, "which is malformed:", err)
+		is_unreachable := map[string]bool{}
+		for _, agent_id := range unreachable_agent_ids {
+			is_unreachable[agent_id] = true
+		}
+		for _, agent_id := range sampled_agent_ids[1:] {
+			if !is_unreachable[agent_id] {
+				forged_agent_ids = append(forged_agent_ids, agent_id)
+			}
+		}
+	}
+	is_proxy_value_verified := liars_network.VerifyAgentValue(public_keys[proxy_agent_id], proxy_agent_id, response.AgentValue, nonce,
+		response.GetSignature())
+	switch {
+	case err != nil:
+		forged_agent_ids = append(forged_agent_ids, proxy_agent_id)
+	case len(forged_agent_ids) != 0:
+		fmt.Fprintln(output, "Discarded", len(forged_agent_ids), "values which are missing or whose signatures failed to verify:",
+			strings.Join(forged_agent_ids, ", "))
+		// A proxy agent which tampered with the values it relayed is a liar, so its own value is discarded too.
+		fmt.Fprintln(output, "Warning: the proxy agent", proxy_agent_id, "tampered with the values it relayed.")
+		forged_agent_ids = append(forged_agent_ids, proxy_agent_id)
+	case !is_proxy_value_verified:
+		fmt.Fprintln(output, "Discarded the value of the proxy agent", proxy_agent_id, "whose signature failed to verify.")
+		forged_agent_ids = append(forged_agent_ids, proxy_agent_id)
+	default:
+		// Append the value from the proxy agent to the collected values from the rest of the network
+		all_values_from_network = append(all_values_from_network, response.AgentValue)
+	}
+	// A proxy agent whose report disagrees with the agents it relayed for tampered with it, so none of the
+	// values it reported, including its own, are trusted. The agents it reported for abstain instead.
+	if result.Consistent != nil && !*result.Consistent {
+		is_abstaining := map[string]bool{}
+		for _, agent_id := range append(response.GetUnreachableAgentIds(), forged_agent_ids...) {
+			is_abstaining[agent_id] = true
+		}
+		for _, agent_id := range sampled_agent_ids {
+			if !is_abstaining[agent_id] {
+				forged_agent_ids = append(forged_agent_ids, agent_id)
+			}
+		}
+		if len(all_values_from_network) != 0 {
+			fmt.Fprintln(output, "Discarded the", len(all_values_from_network), "values reported by the proxy agent", proxy_agent_id,
+				"as its report is inconsistent.")
+		}
+		all_values_from_network = nil
 	}
 
-	// Append the value from the proxy agent to the collected values from the rest of the network
-	all_values_from_network := response.GetCollectedAgentValues()
-	all_values_from_network = append(all_values_from_network, response.AgentValue)
+	// By default, if the whole network is sampled, the network value is found by finding the unique element
+	// from the slice which matches the same frequncy, which is the number of honest agents in the network minus
+	// the honest agents which may have abstained. If there are more than one value whose frequency matches, then
+	// a correct network value cannot be decided. Otherwise, the network value is inferred from the partial
+	// sample of the agents which responded.
+	decider := SelectDecider(playexpert_command.DeciderFlags, liars_network.NetworkAssumptions{HonestAgentsNum: assumed_frequency,
+		AbstentionsNum: len(unreachable_agent_ids) + len(forged_agent_ids), IsPartialSample: num_agents != len(launched_agents_list),
+		LiarRatio: liar_ratio, MaxValue: network_state.max_value})
+	result.Responses = all_values_from_network
+	result.Abstentions = append(unreachable_agent_ids, forged_agent_ids...)
+	result.Forged = forged_agent_ids
+	PrintDecision(decider.Decide(all_values_from_network), result)
+	return true
+}
 
-	// The network value is found by finding the unique element from the slice which matches the same frequncy,
-	// which is the number of honest agents in the network. If there are more than one value whose frequency
-	// matches the number of honest agents, then a correct network value cannot be decided.
-	if network_value, exists := liars_network.FindNetworkValue(all_values_from_network, assumed_frequency); exists {
-		fmt.Println("The network value is ", network_value)
-	} else {
-		fmt.Println("The network value cannot be decided because the liar agents successfully fooled the client.")
+// Spot-checks verified_agents_num of the values the proxy agent collected from the other agents against
+// the values those agents signed along with nonce, which a tampering proxy agent cannot forge. Unlike
+// querying the agents again, this holds for liars which do not answer consistently, such as random and
+// flip liars. Returns whether the report of the proxy agent is consistent.
+func VerifyProxyReport(proxy_agent_id string, other_agent_ids []string, response *liars_network.LieResponse,
+	verified_agents_num int, nonce []byte, public_keys map[string]ed25519.PublicKey) bool {
+	is_unreachable := map[string]bool{}
+	for _, agent_id := range response.GetUnreachableAgentIds() {
+		is_unreachable[agent_id] = true
+	}
+	// The collected values are in the same order as the agents which the proxy agent could reach.
+	var reported_agent_ids []string
+	for _, agent_id := range other_agent_ids {
+		if !is_unreachable[agent_id] {
+			reported_agent_ids = append(reported_agent_ids, agent_id)
+		}
+	}
+	reported_values := response.GetCollectedAgentValues()
+	if len(reported_values) != len(reported_agent_ids) {
+		fmt.Fprintln(output, "Warning: the proxy agent", proxy_agent_id, "reported", len(reported_values), "values for",
+			len(reported_agent_ids), "agents, so its report is inconsistent.")
+		return false
+	}
+	if verified_agents_num > len(reported_agent_ids) {
+		verified_agents_num = len(reported_agent_ids)
+	}
+	signed_values := map[string]*liars_network.SignedValue{}
+	for _, signed_value := range response.GetSignedValues() {
+		signed_values[signed_value.GetAgentId()] = signed_value
+	}
+	mismatches_num := 0
+	for _, index := range rand.Perm(len(reported_agent_ids))[:verified_agents_num] {
+		agent_id, reported_value := reported_agent_ids[index], reported_values[index]
+		signed_value, exists := signed_values[agent_id]
+		if !exists || signed_value.GetValue() != reported_value ||
+			!liars_network.VerifyAgentValue(public_keys[agent_id], agent_id, reported_value, nonce, signed_value.GetSignature()) {
+			mismatches_num++
+			fmt.Fprintln(output, "Agent", agent_id, "did not sign the value", reported_value, "reported by the proxy agent", proxy_agent_id)
+		}
+	}
+	if mismatches_num != 0 {
+		fmt.Fprintln(output, "Warning: the report of the proxy agent", proxy_agent_id, "is inconsistent with", mismatches_num, "out of",
+			verified_agents_num, "spot-checked agents, so it may have tampered with the relayed values.")
+		return false
 	}
+	fmt.Fprintln(output, "The report of the proxy agent", proxy_agent_id, "is consistent with", verified_agents_num, "spot-checked agents.")
 	return true
 }
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoooGu/liarslie/liars_network"
+)
+
+// Returns the state of a client in curr_mode whose agents.config is kept in a temporary directory, and
+// collects the messages of the client and of its agents until the test is over.
+func newTestClientState(t *testing.T, curr_mode ModeType) (*ClientState, *MessageCollector) {
+	collector := new(MessageCollector)
+	output = collector
+	liars_network.SetOutput(collector)
+	t.Cleanup(func() {
+		output = os.Stdout
+		liars_network.SetOutput(os.Stdout)
+	})
+	client_state := &ClientState{curr_mode: curr_mode, registry: liars_network.NewRegistry(filepath.Join(t.TempDir(), "agents.config")),
+		launch_options: LaunchOptions{host: "localhost"}, query_options: QueryOptions{workers_num: 4, timeout: time.Second}}
+	return client_state, collector
+}
+
+// Launches an agent holding agent_value, out of a network whose value is 5, which tampers with the values it
+// relays with tamper_probability, until the test is over.
+func launchTestAgent(t *testing.T, strategy_type liars_network.StrategyType, agent_value int32,
+	tamper_probability float64) *liars_network.Agent {
+	var wait_group sync.WaitGroup
+	wait_group.Add(1)
+	port_number := make(chan int)
+	agent := new(liars_network.Agent)
+	random := liars_network.NewRandom(time.Now().UnixNano())
+	agent.SetRandom(random)
+	agent.SetTamperProbability(tamper_probability)
+	go agent.Init(port_number, "localhost:0", liars_network.NewStrategy(strategy_type, agent_value, 5, 10, random), &wait_group)
+	<-port_number
+	wait_group.Wait()
+	t.Cleanup(agent.Stop)
+	return agent
+}
+
+func containsLine(lines []string, prefix string) bool {
+	for _, line := range lines {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func countLines(lines []string, prefix string) int {
+	lines_num := 0
+	for _, line := range lines {
+		if strings.HasPrefix(line, prefix) {
+			lines_num++
+		}
+	}
+	return lines_num
+}
+
+func TestRunCommandsSkipsCommentsAndBlankLines(t *testing.T) {
+	client_state, collector := newTestClientState(t, STANDARD)
+	if !RunCommands(client_state, strings.NewReader("\n# mode bogus\n   \n\t# indented comment\nmode expert\n")) {
+		t.Errorf("Comments and blank lines should not fail, got %v", collector.Flush())
+	}
+	if client_state.curr_mode != EXPERT {
+		t.Errorf("The mode command should still run after the comments, got %s", client_state.curr_mode)
+	}
+	if lines := collector.Flush(); containsLine(lines, "Cannot recognize command") || containsLine(lines, "Please") {
+		t.Errorf("Comments and blank lines should not run as commands, got %v", lines)
+	}
+}
+
+func TestRunCommandsFailedCommand(t *testing.T) {
+	client_state, collector := newTestClientState(t, STANDARD)
+	if RunCommands(client_state, strings.NewReader("bogus\nmode expert\n")) {
+		t.Errorf("A failed command should fail the run")
+	}
+	if lines := collector.Flush(); !containsLine(lines, "Cannot recognize command: bogus") || client_state.curr_mode != EXPERT {
+		t.Errorf("The commands after a failed one should still run, got %v", lines)
+	}
+}
+
+func TestRunCommandsQuotedCommandName(t *testing.T) {
+	client_state, collector := newTestClientState(t, STANDARD)
+	// The name of a command is tokenized the same as its arguments.
+	if !RunCommands(client_state, strings.NewReader("\"mode\" expert\n")) || client_state.curr_mode != EXPERT {
+		t.Errorf("A quoted command name should be unquoted, got %v", collector.Flush())
+	}
+	if !RunCommands(client_state, strings.NewReader("mo\\de 'standard'\n")) || client_state.curr_mode != STANDARD {
+		t.Errorf("An escaped command name should be unescaped, got %v", collector.Flush())
+	}
+	if RunCommands(client_state, strings.NewReader("\"mode expert\n")) {
+		t.Errorf("A command with an unterminated quote should fail")
+	}
+	if lines := collector.Flush(); containsLine(lines, "Cannot recognize command") || client_state.curr_mode != STANDARD {
+		t.Errorf("A command with an unterminated quote should report where it is malformed, got %v", lines)
+	}
+}
+
+func TestRunCommandsStopsAgentsAtEOF(t *testing.T) {
+	start := "start --value 5 --max-value 10 --num-agents 3 --liar-ratio 0"
+	extend := "extend --value 5 --max-value 10 --num-agents 3 --liar-ratio 0"
+	for _, test_case := range []struct {
+		curr_mode ModeType
+		script    string
+	}{
+		{STANDARD, start},
+		{EXPERT, extend},
+		// Switching modes does not change that agents running as goroutines die along with the client.
+		{STANDARD, start + "\nmode expert"},
+	} {
+		client_state, collector := newTestClientState(t, test_case.curr_mode)
+		config_path := filepath.Join(t.TempDir(), "agents.config")
+		client_state.registry = liars_network.NewRegistry(config_path)
+		if !RunCommands(client_state, strings.NewReader(test_case.script+"\n")) {
+			t.Fatalf("%q should succeed, got %v", test_case.script, collector.Flush())
+		}
+		// Agents running as goroutines die along with the client, so they are stopped and forgotten.
+		if len(client_state.registry.Agents()) != 0 || len(client_state.registry.Records()) != 0 {
+			t.Errorf("The end of input should stop the agents after %q", test_case.script)
+		}
+		if _, err := os.Stat(config_path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("The end of input should delete agents.config after %q", test_case.script)
+		}
+	}
+}
+
+func TestRunCommandsStopsAgentProcessesAtEOF(t *testing.T) {
+	client_state, _ := newTestClientState(t, EXPERT)
+	client_state.launch_options.is_process_mode = true
+	// Stands in for an agent process, which cannot be launched from the test binary.
+	agent := launchTestAgent(t, liars_network.CONSTANT_LIE, 5, 0)
+	port_number, _ := strconv.Atoi(agent.RetrievePortNum())
+	record := &liars_network.AgentRecord{Host: "localhost", Port: port_number}
+	if err := client_state.registry.Add(agent, record); err != nil {
+		t.Fatalf("Failed to add the agent: %s", err)
+	}
+	if !RunCommands(client_state, strings.NewReader("")) {
+		t.Errorf("An empty input should succeed")
+	}
+	// No other client could play with or stop the agent processes, so they are stopped even in expert mode.
+	if len(client_state.registry.Agents()) != 0 || len(client_state.registry.Records()) != 0 {
+		t.Errorf("The end of input should stop the agent processes in expert mode")
+	}
+	if result := liars_network.QueryAgent(agent.RetrieveAddress(), new(liars_network.LieRequest), time.Second); result.Err == nil {
+		t.Errorf("The stopped agent should not answer, got %+v", result)
+	}
+}
+
+func TestRunCommandsJSONOutput(t *testing.T) {
+	client_state, collector := newTestClientState(t, STANDARD)
+	client_state.collector = collector
+	var results bytes.Buffer
+	result_output = &results
+	defer func() { result_output = os.Stdout }()
+	script := "start --value 5 --max-value 10 --num-agents 3 --liar-ratio 0\nplay\nbogus\n"
+	if RunCommands(client_state, strings.NewReader(script)) {
+		t.Errorf("The bogus command should fail the run")
+	}
+
+	// Every command, including the stop at the end of input, is written as one object along with the
+	// messages printed while it ran.
+	decoder := json.NewDecoder(&results)
+	var decoded_results []CommandResult
+	for decoder.More() {
+		var result CommandResult
+		if err := decoder.Decode(&result); err != nil {
+			t.Fatalf("Failed to decode the result: %s", err)
+		}
+		decoded_results = append(decoded_results, result)
+	}
+	expected_commands, expected_oks := []string{"start", "play", "bogus", "stop"}, []bool{true, true, false, true}
+	if len(decoded_results) != len(expected_commands) {
+		t.Fatalf("There should be one result per command, got %+v", decoded_results)
+	}
+	for i, result := range decoded_results {
+		if result.Command != expected_commands[i] || result.Ok != expected_oks[i] {
+			t.Errorf("Result %d should be of %s with ok %v, got %+v", i, expected_commands[i], expected_oks[i], result)
+		}
+	}
+	if len(decoded_results[0].Agents) != 3 || !containsLine(decoded_results[0].Messages, "Ready") {
+		t.Errorf("start should report its 3 agents, got %+v", decoded_results[0])
+	}
+	if decoded_results[1].Decision == nil || decoded_results[1].Decision.Value != 5 || len(decoded_results[1].Responses) != 3 {
+		t.Errorf("play should decide 5 from 3 responses, got %+v", decoded_results[1])
+	}
+	if !containsLine(decoded_results[2].Errors, "Cannot recognize command: bogus") {
+		t.Errorf("bogus should report why it failed, got %+v", decoded_results[2])
+	}
+	// The agents print that they stop while the stop at the end of input runs, not during a later command.
+	for i, result := range decoded_results {
+		if stops_num := countLines(append(result.Messages, result.Errors...), "Stopping grpc server"); (i == 3) != (stops_num == 3) {
+			t.Errorf("Only stop should report the 3 agents stopping, got %+v", result)
+		}
+	}
+}
+
+func TestSweepCommandJSONOutput(t *testing.T) {
+	client_state, collector := newTestClientState(t, STANDARD)
+	client_state.collector = collector
+	var results bytes.Buffer
+	result_output = &results
+	defer func() { result_output = os.Stdout }()
+	if !RunCommands(client_state, strings.NewReader("sweep --trials 10 --num-agents 4 --liar-ratios 0,0.5 --max-values 2\n")) {
+		t.Fatalf("The sweep should succeed, got %s", results.String())
+	}
+
+	// The rows are only written as structured JSON, not as a table among the messages.
+	var result CommandResult
+	if err := json.NewDecoder(&results).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode the result: %s", err)
+	}
+	if len(result.Sweep) != 2 || result.Sweep[0].SuccessRate != 1 || result.Sweep[1].TieRate != 1 {
+		t.Errorf("sweep should report its 2 rows, got %+v", result.Sweep)
+	}
+	if containsLine(result.Messages, "liar_ratio") {
+		t.Errorf("sweep should not write its table among the messages, got %v", result.Messages)
+	}
+}
+
+func TestReconcileNetworkState(t *testing.T) {
+	honest := liars_network.AgentRecord{Role: liars_network.HONEST_ROLE}
+	liar := liars_network.AgentRecord{Role: liars_network.LIAR_ROLE}
+	unaudited := liars_network.AgentRecord{}
+	for _, test_case := range []struct {
+		name                       string
+		liar_ratio                 float64
+		records                    []liars_network.AgentRecord
+		expected_honest_agents_num int
+	}{
+		{"no agents", 0.5, nil, 0},
+		{"audited", 0.5, []liars_network.AgentRecord{honest, liar, honest}, 2},
+		{"audited after a kill", 0.25, []liars_network.AgentRecord{liar, honest}, 1},
+		{"unaudited", 0.25, []liars_network.AgentRecord{unaudited, unaudited, unaudited, unaudited}, 3},
+		{"unaudited without liars", 0, []liars_network.AgentRecord{unaudited, unaudited}, 2},
+		// The roles are only counted if every agent has one, as the others may be of another client.
+		{"partly audited", 0.5, []liars_network.AgentRecord{honest, honest, unaudited, liar}, 2},
+	} {
+		network_state := NetworkState{liar_ratio: test_case.liar_ratio, honest_agents_num: -1}
+		ReconcileNetworkState(&network_state, test_case.records)
+		if network_state.honest_agents_num != test_case.expected_honest_agents_num {
+			t.Errorf("%s: there should be %d honest agents, got %d", test_case.name, test_case.expected_honest_agents_num,
+				network_state.honest_agents_num)
+		}
+	}
+}
+
+func TestReplayTranscript(t *testing.T) {
+	// Records a session the same as a client started with -transcript.
+	client_state, collector := newTestClientState(t, STANDARD)
+	recorded_path := filepath.Join(t.TempDir(), "recorded.jsonl")
+	var err error
+	if client_state.transcript, err = liars_network.OpenTranscript(recorded_path); err != nil {
+		t.Fatalf("Failed to open the transcript: %s", err)
+	}
+	session := &liars_network.TranscriptSession{Seed: 7, Mode: STANDARD.String(), Host: "localhost"}
+	if err := client_state.transcript.Record(liars_network.TranscriptEntry{Type: liars_network.SESSION_ENTRY, Session: session}); err != nil {
+		t.Fatalf("Failed to write the transcript: %s", err)
+	}
+	if !RunCommands(client_state, strings.NewReader("start --value 5 --max-value 10 --num-agents 4 --liar-ratio 0.25\nplay\nstop\n")) {
+		t.Fatalf("The recorded commands should succeed, got %v", collector.Flush())
+	}
+	client_state.transcript.Close()
+
+	// Replays the session in another client, which keeps a transcript of its own.
+	client_state, collector = newTestClientState(t, EXPERT)
+	client_state.launch_options.host = "127.0.0.1"
+	replayed_path := filepath.Join(t.TempDir(), "replayed.jsonl")
+	if client_state.transcript, err = liars_network.OpenTranscript(replayed_path); err != nil {
+		t.Fatalf("Failed to open the transcript: %s", err)
+	}
+	client_state.session = &liars_network.TranscriptSession{Seed: 3, Mode: EXPERT.String(), Host: "127.0.0.1"}
+	if err := client_state.transcript.Record(liars_network.TranscriptEntry{Type: liars_network.SESSION_ENTRY,
+		Session: client_state.session}); err != nil {
+		t.Fatalf("Failed to write the transcript: %s", err)
+	}
+	if !RunCommands(client_state, strings.NewReader("replay --file "+recorded_path+"\n")) {
+		t.Fatalf("The replay should succeed, got %v", collector.Flush())
+	}
+	client_state.transcript.Close()
+	if lines := collector.Flush(); !containsLine(lines, "Replayed 3 commands, 0 of which") {
+		t.Errorf("The replayed commands should have the recorded outcomes, got %v", lines)
+	}
+	// The client carries on as before the replay, rather than as at the end of the replayed session.
+	if client_state.curr_mode != EXPERT || client_state.launch_options.host != "127.0.0.1" {
+		t.Errorf("The replay should restore expert mode and the host, got %s mode and %s", client_state.curr_mode,
+			client_state.launch_options.host)
+	}
+
+	// The replayed commands are recorded in a session of their own. The replay itself is recorded in the
+	// session which follows, started the same as the session before the replay.
+	entries, err := liars_network.ReadTranscript(replayed_path)
+	if err != nil {
+		t.Fatalf("Failed to read the transcript: %s", err)
+	}
+	var commands []string
+	for _, entry := range entries {
+		if entry.Type == liars_network.COMMAND_ENTRY {
+			commands = append(commands, strings.Fields(entry.Command)[0])
+		}
+	}
+	sessions := liars_network.TranscriptSessions(entries)
+	if len(sessions) != 3 || sessions[1][0].Session.Seed != 7 || len(sessions[1]) == 1 || sessions[2][0].Session.Mode != EXPERT.String() ||
+		sessions[2][0].Session.Host != "127.0.0.1" || !strings.HasPrefix(sessions[2][1].Command, "replay") || strings.Join(commands, " ") != "start play stop replay" {
+		t.Errorf("The transcript should record the replayed session and its commands, got %v", commands)
+	}
+}
+
+func TestVerifyProxyReport(t *testing.T) {
+	newTestClientState(t, EXPERT)
+	// Random liars answer differently every time they are asked, which an honest proxy agent still
+	// reports consistently, while a tampering proxy agent does not.
+	other_agents := []*liars_network.Agent{launchTestAgent(t, liars_network.RANDOM_LIE, 7, 0),
+		launchTestAgent(t, liars_network.RANDOM_LIE, 7, 0), launchTestAgent(t, liars_network.CONSTANT_LIE, 5, 0)}
+	public_keys := map[string]ed25519.PublicKey{}
+	var other_agent_ids []string
+	for _, agent := range other_agents {
+		other_agent_ids = append(other_agent_ids, agent.RetrieveAddress())
+		public_keys[agent.RetrieveAddress()] = agent.RetrievePublicKey()
+	}
+	for _, test_case := range []struct {
+		tamper_probability float64
+		is_consistent      bool
+	}{{0, true}, {1, false}} {
+		proxy_agent := launchTestAgent(t, liars_network.CONSTANT_LIE, 9, test_case.tamper_probability)
+		nonce := liars_network.NewNonce()
+		query_result := liars_network.QueryAgent(proxy_agent.RetrieveAddress(),
+			&liars_network.LieRequest{ExpertMode: true, OtherAgentIds: other_agent_ids, Nonce: nonce}, time.Second)
+		if query_result.Err != nil {
+			t.Fatalf("Failed to query the proxy agent: %s", query_result.Err)
+		}
+		if is_consistent := VerifyProxyReport(proxy_agent.RetrieveAddress(), other_agent_ids, query_result.Response, len(other_agent_ids),
+			nonce, public_keys); is_consistent != test_case.is_consistent {
+			t.Errorf("The report of a proxy agent which tampers with probability %v should be consistent: %v, got %v",
+				test_case.tamper_probability, test_case.is_consistent, is_consistent)
+		}
+	}
+}
@@ -1,36 +1,122 @@
 package liars_network
 
 import (
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
 	"fmt"
 	"log"
+	"math/rand"
 	"net"
+	"os"
 	"strconv"
 	"sync"
+	"time"
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
 )
 
+// A LaunchedAgent is an agent launched by the client, which either runs as a goroutine of the client
+// (Agent) or as a separate OS process (AgentProcess).
+type LaunchedAgent interface {
+	// Stops the agent gracefully.
+	Stop()
+	// Stops the agent abruptly, as if it crashed.
+	Kill()
+	// Replaces the strategy of the agent with the one created by NewStrategy from the arguments, and
+	// sets the probability with which the agent tampers with the values it relays. If it fails, the agent
+	// is no longer running.
+	Reassign(strategy_type StrategyType, agent_value int32, network_value int32, max_value int32, tamper_probability float64) error
+	IsMatchingAddress(address string) bool
+	RetrievePortNum() string
+	// The host:port address through which the agent is reached.
+	RetrieveAddress() string
+	RetrievePid() int
+	// The key which verifies the signatures of the agent over its values.
+	RetrievePublicKey() ed25519.PublicKey
+	// The address the agent serves its own metrics over, or "" if they are served along with those of the client.
+	RetrieveMetricsAddress() string
+}
+
+const (
+	// The max number of agents a proxy agent queries at the same time.
+	PROXY_WORKERS_NUM = 64
+	// How long a proxy agent waits for the response of a single agent, unless the request says otherwise.
+	PROXY_QUERY_TIMEOUT = 5 * time.Second
+)
+
+// The longest a proxy agent takes to collect values from other_agents_num agents, waiting query_timeout
+// for each of them.
+func ProxyFanOutTimeout(other_agents_num int, query_timeout time.Duration) time.Duration {
+	rounds_num := (other_agents_num + PROXY_WORKERS_NUM - 1) / PROXY_WORKERS_NUM
+	return time.Duration(rounds_num) * query_timeout
+}
+
+// Converts a timeout in milliseconds carried by a request, returning default_timeout if it is not positive.
+func requestTimeout(timeout_ms int64, default_timeout time.Duration) time.Duration {
+	if timeout_ms <= 0 {
+		return default_timeout
+	}
+	return time.Duration(timeout_ms) * time.Millisecond
+}
+
 type Agent struct {
+	host        string
 	port_number int
 	grpc_server *grpc.Server
-	value       int32
+	// The strategy and the tamper probability are swapped by Reassign while the agent answers queries.
+	strategy_mutex sync.Mutex
+	strategy       Strategy
+	public_key     ed25519.PublicKey
+	private_key    ed25519.PrivateKey
+	// The credentials the agent serves with, or nil to serve without TLS.
+	server_credentials credentials.TransportCredentials
+	// The probability with which the agent replaces every value it relays with its own answer.
+	tamper_probability float64
+	// What the agent draws its random lies and tampering from.
+	random *rand.Rand
+	// The runs of the PBFT protocol the agent takes part in, by sequence.
+	pbft_mutex     sync.Mutex
+	pbft_instances map[int64]*pbftInstance
+	// The agents this agent gossips with.
+	neighbors_mutex sync.Mutex
+	neighbors       []string
+	// The gossip the agent already relayed, by nonce.
+	seen_gossip_mutex sync.Mutex
+	seen_gossip       map[string]*seenGossip
 	UnimplementedLieServiceServer
 }
 
-func (agent *Agent) Init(port_number chan int, agent_value int32, wg *sync.WaitGroup) {
-	agent.value = agent_value
+// Starts serving LieQuery over bind_address, which is of the form host:port. If the port is 0, the
+// agent listens on the next available port instead. If the host is empty or unspecified, the agent
+// listens on all the interfaces and is reached through localhost. The agent generates a new key pair
+// to sign its values with.
+func (agent *Agent) Init(port_number chan int, bind_address string, strategy Strategy, wg *sync.WaitGroup) {
+	agent.UpdateStrategy(strategy)
+	if agent.random == nil {
+		agent.random = NewRandom(time.Now().UnixNano())
+	}
+	var err error
+	if agent.public_key, agent.private_key, err = ed25519.GenerateKey(crypto_rand.Reader); err != nil {
+		log.Fatalln("Failed to generate the key pair of the agent: ", err)
+	}
 
-	conn, err := net.Listen("tcp", ":0")
+	conn, err := net.Listen("tcp", bind_address)
 	if err != nil {
-		log.Fatalln("Failed to find the next available port: ", err)
+		log.Fatalln("Failed to listen on address: ", bind_address, err)
 	}
-	// This port number is the next arbitrary free available one in the network
+	bind_host, _, _ := net.SplitHostPort(bind_address)
+	agent.host = ReachableHost(bind_host)
+	// This port number is either the one in bind_address or the next arbitrary free available one in the network
 	agent.port_number = conn.Addr().(*net.TCPAddr).Port
 	port_number <- agent.port_number
 	// Creates a grpc server over the port that was just found
-	agent.grpc_server = grpc.NewServer()
+	var server_options []grpc.ServerOption
+	if agent.server_credentials != nil {
+		server_options = append(server_options, grpc.Creds(agent.server_credentials))
+	}
+	agent.grpc_server = grpc.NewServer(server_options...)
 	RegisterLieServiceServer(agent.grpc_server, agent)
 	wg.Done()
 	if err := agent.grpc_server.Serve(conn); err != nil {
@@ -39,39 +125,167 @@ func (agent *Agent) Init(port_number chan int, agent_value int32, wg *sync.WaitG
 }
 
 func (agent *Agent) LieQuery(ctx context.Context, lie_request *LieRequest) (*LieResponse, error) {
+	defer agent.observeLieQuery(lie_request, time.Now())
+	if lie_request.GetExpertMode() && lie_request.GetHops() > 0 {
+		return agent.gossipLieQuery(lie_request), nil
+	}
 	if lie_request.GetExpertMode() {
+		var addresses []string
+		for _, agent_id := range lie_request.GetOtherAgentIds() {
+			addresses = append(addresses, AgentAddress(agent_id))
+		}
+		// An agent which fails to respond does not fail the whole request; it is reported back as
+		// unreachable instead.
 		var collected_agent_values []int32
-		for _, port_number := range lie_request.GetOtherAgentIds() {
-			conn, err := grpc.Dial(":"+port_number, grpc.WithTransportCredentials(insecure.NewCredentials()))
-			if err != nil {
-				return nil, err
-			}
-			defer conn.Close()
-			internal_client := NewLieServiceClient(conn)
-			response, err := internal_client.LieQuery(context.Background(), new(LieRequest))
-			if err != nil {
-				return nil, err
+		var unreachable_agent_ids []string
+		var signed_values []*SignedValue
+		agent_value := agent.answer()
+		// The other agents sign their values with the nonce of the client, which the proxy agent cannot forge.
+		results := QueryAgents(addresses, &LieRequest{Nonce: lie_request.GetNonce()}, PROXY_WORKERS_NUM,
+			requestTimeout(lie_request.GetQueryTimeoutMs(), PROXY_QUERY_TIMEOUT))
+		agent_fan_outs_total.Inc(agent.RetrieveAddress())
+		for i, result := range results {
+			if result.Err != nil {
+				unreachable_agent_ids = append(unreachable_agent_ids, lie_request.GetOtherAgentIds()[i])
+				continue
 			}
-			collected_agent_values = append(collected_agent_values, response.AgentValue)
+			relayed_value := agent.relay(result.Response.AgentValue, agent_value)
+			collected_agent_values = append(collected_agent_values, relayed_value)
+			signed_values = append(signed_values, &SignedValue{AgentId: lie_request.GetOtherAgentIds()[i], Value: relayed_value,
+				Signature: result.Response.GetSignature()})
 		}
-		return &LieResponse{CollectedAgentValues: collected_agent_values, AgentValue: agent.value}, nil
+		agent_errors_total.Add(float64(len(unreachable_agent_ids)), agent.RetrieveAddress())
+		return &LieResponse{CollectedAgentValues: collected_agent_values, AgentValue: agent_value,
+			UnreachableAgentIds: unreachable_agent_ids, Signature: agent.sign(agent_value, lie_request.GetNonce()),
+			SignedValues: signed_values}, nil
+	}
+	agent_value := agent.answer()
+	return &LieResponse{AgentValue: agent_value, Signature: agent.sign(agent_value, lie_request.GetNonce())}, nil
+}
+
+// Counts a LieQuery call which started at start_time, along with how long it took.
+func (agent *Agent) observeLieQuery(lie_request *LieRequest, start_time time.Time) {
+	kind := "direct"
+	if lie_request.GetExpertMode() && lie_request.GetHops() > 0 {
+		kind = "gossip"
+	} else if lie_request.GetExpertMode() {
+		kind = "expert"
 	}
-	return &LieResponse{AgentValue: agent.value}, nil
+	agent_lie_queries_total.Inc(agent.RetrieveAddress(), kind)
+	agent_lie_query_duration_seconds.Observe(time.Since(start_time).Seconds(), agent.RetrieveAddress(), kind)
 }
 
 func (agent *Agent) Stop() {
-	fmt.Println("Stopping grpc server on port number: ", agent.port_number)
+	fmt.Fprintln(output, "Stopping grpc server on port number: ", agent.port_number)
 	agent.grpc_server.Stop()
 }
 
-func (agent *Agent) UpdateValue(value int32) {
-	agent.value = value
+// An agent running as a goroutine cannot crash on its own, so killing it is the same as stopping it.
+func (agent *Agent) Kill() {
+	agent.Stop()
+}
+
+func (agent *Agent) UpdateStrategy(strategy Strategy) {
+	agent.strategy_mutex.Lock()
+	defer agent.strategy_mutex.Unlock()
+	agent.strategy = strategy
+}
+
+// Answers a query with the current strategy of the agent.
+func (agent *Agent) answer() int32 {
+	agent.strategy_mutex.Lock()
+	strategy := agent.strategy
+	agent.strategy_mutex.Unlock()
+	return strategy.Answer()
 }
 
-func (agent *Agent) IsMatchingPortNumber(port_number int) bool {
-	return agent.port_number == port_number
+// Returns the value the current strategy of the agent holds, without answering a query.
+func (agent *Agent) value() int32 {
+	agent.strategy_mutex.Lock()
+	defer agent.strategy_mutex.Unlock()
+	return agent.strategy.Value()
+}
+
+// Must be called before Init for the agent to serve over TLS.
+func (agent *Agent) SetServerCredentials(server_credentials credentials.TransportCredentials) {
+	agent.server_credentials = server_credentials
+}
+
+// Must be called before Init for the agent to draw from random rather than from a generator seeded
+// with the current time. random should be the same one the strategy of the agent draws from.
+func (agent *Agent) SetRandom(random *rand.Rand) {
+	agent.random = random
+}
+
+// Must be called before Init for the agent to tamper with the values it relays from the start.
+func (agent *Agent) SetTamperProbability(tamper_probability float64) {
+	agent.strategy_mutex.Lock()
+	defer agent.strategy_mutex.Unlock()
+	agent.tamper_probability = tamper_probability
+}
+
+func (agent *Agent) Reassign(strategy_type StrategyType, agent_value int32, network_value int32, max_value int32,
+	tamper_probability float64) error {
+	strategy := NewStrategy(strategy_type, agent_value, network_value, max_value, agent.random)
+	agent.strategy_mutex.Lock()
+	defer agent.strategy_mutex.Unlock()
+	agent.strategy = strategy
+	agent.tamper_probability = tamper_probability
+	return nil
+}
+
+// Returns the value the agent relays in place of relayed_value. A tampering agent replaces it with
+// agent_value, its own answer to the query, which makes its lie look more popular than it is.
+func (agent *Agent) relay(relayed_value int32, agent_value int32) int32 {
+	agent.strategy_mutex.Lock()
+	tamper_probability := agent.tamper_probability
+	agent.strategy_mutex.Unlock()
+	if tamper_probability > 0 && agent.random.Float64() < tamper_probability {
+		return agent_value
+	}
+	return relayed_value
+}
+
+func (agent *Agent) IsMatchingAddress(address string) bool {
+	return agent.RetrieveAddress() == address
 }
 
 func (agent *Agent) RetrievePortNum() string {
 	return strconv.FormatInt(int64(agent.port_number), 10)
 }
+
+// An agent running as a goroutine shares the process of the client.
+func (agent *Agent) RetrievePid() int {
+	return os.Getpid()
+}
+
+func (agent *Agent) RetrieveAddress() string {
+	return net.JoinHostPort(agent.host, agent.RetrievePortNum())
+}
+
+func (agent *Agent) RetrievePublicKey() ed25519.PublicKey {
+	return agent.public_key
+}
+
+// An agent running as a goroutine shares the metrics of the client.
+func (agent *Agent) RetrieveMetricsAddress() string {
+	return ""
+}
+
+// Turns the id of an agent into the address to dial. An id is either a host:port address or, as
+// sent by older clients, a bare port number of an agent on the same machine.
+func AgentAddress(agent_id string) string {
+	if _, err := strconv.Atoi(agent_id); err == nil {
+		return ":" + agent_id
+	}
+	return agent_id
+}
+
+// Returns the host through which an agent bound to bind_host is reached. An agent bound to all the
+// interfaces is reached through localhost.
+func ReachableHost(bind_host string) string {
+	if ip := net.ParseIP(bind_host); bind_host == "" || ip != nil && ip.IsUnspecified() {
+		return "localhost"
+	}
+	return bind_host
+}
@@ -0,0 +1,102 @@
+package liars_network
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReassignWhileQueried(t *testing.T) {
+	var wait_group sync.WaitGroup
+	wait_group.Add(1)
+	port_number := make(chan int)
+	agent := new(Agent)
+	go agent.Init(port_number, "127.0.0.1:0", &HonestStrategy{value: 3}, &wait_group)
+	<-port_number
+	wait_group.Wait()
+	defer agent.Stop()
+
+	// Reassigning the strategy races with the queries unless it is guarded, which go test -race reports.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			agent.Reassign(CONSTANT_LIE, 7, 3, 10, 0)
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		result := QueryAgent(agent.RetrieveAddress(), new(LieRequest), time.Second)
+		if result.Err != nil || (result.Response.AgentValue != 3 && result.Response.AgentValue != 7) {
+			t.Errorf("The agent should answer with either strategy, got %+v", result)
+		}
+	}
+	<-done
+	if answer := agent.answer(); answer != 7 {
+		t.Errorf("The agent should answer with its last strategy, got %v", answer)
+	}
+}
+
+func TestReassignWhileTampering(t *testing.T) {
+	var wait_group sync.WaitGroup
+	wait_group.Add(1)
+	port_number := make(chan int)
+	proxy_agent := new(Agent)
+	proxy_agent.SetTamperProbability(0.5)
+	go proxy_agent.Init(port_number, "127.0.0.1:0", &ConstantLie{value: 9}, &wait_group)
+	<-port_number
+	wait_group.Wait()
+	defer proxy_agent.Stop()
+	other_agent_ids := []string{launchTestAgent(t, 3), launchTestAgent(t, 3)}
+
+	// Reassigning the tamper probability races with the values the agent relays unless it is guarded,
+	// which go test -race reports.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			proxy_agent.Reassign(CONSTANT_LIE, 9, 3, 10, float64(i%2))
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		result := QueryAgent(proxy_agent.RetrieveAddress(), &LieRequest{ExpertMode: true, OtherAgentIds: other_agent_ids}, time.Second)
+		if result.Err != nil || len(result.Response.CollectedAgentValues) != 2 {
+			t.Fatalf("The proxy agent should relay both values, got %+v", result)
+		}
+		for _, value := range result.Response.CollectedAgentValues {
+			if value != 3 && value != 9 {
+				t.Errorf("The proxy agent should relay either the value or its own lie, got %v", result.Response.CollectedAgentValues)
+			}
+		}
+	}
+	<-done
+}
+
+func TestAgentAddress(t *testing.T) {
+	agent_ids := map[string]string{
+		"4000":           ":4000",
+		"10.0.0.2:4000":  "10.0.0.2:4000",
+		"localhost:4000": "localhost:4000",
+		"[::1]:4000":     "[::1]:4000",
+	}
+	for agent_id, expected_address := range agent_ids {
+		if address := AgentAddress(agent_id); address != expected_address {
+			t.Errorf("Expected the agent %s to be reached through %s, got %s", agent_id, expected_address, address)
+		}
+	}
+}
+
+func TestReachableHost(t *testing.T) {
+	bind_hosts := map[string]string{
+		"":          "localhost",
+		"0.0.0.0":   "localhost",
+		"::":        "localhost",
+		"127.0.0.1": "127.0.0.1",
+		"10.0.0.2":  "10.0.0.2",
+		"agent.lan": "agent.lan",
+	}
+	for bind_host, expected_host := range bind_hosts {
+		if host := ReachableHost(bind_host); host != expected_host {
+			t.Errorf("Expected an agent bound to %q to be reached through %s, got %s", bind_host, expected_host, host)
+		}
+	}
+}
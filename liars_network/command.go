@@ -0,0 +1,250 @@
+package liars_network
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Every command is a name followed by flags, each of which is either `--name value` or `--name=value`.
+// Tokens are separated by any amount of whitespace. A value containing whitespace can be quoted with
+// single or double quotes, and a backslash escapes the next character outside of single quotes.
+
+// A word of a command, along with the byte offset in the command where it starts.
+type Token struct {
+	Text   string
+	Offset int
+}
+
+// An error in a command, which points at the token causing it.
+type ParseError struct {
+	Command string
+	// The byte offset of the offending token, or the length of the command if a token is missing.
+	Offset  int
+	Message string
+}
+
+// Describes the error, followed by the command and a caret under the offending token.
+func (parse_error *ParseError) Error() string {
+	return fmt.Sprintf("%s\n%s\n%s^", parse_error.Message, parse_error.Command, strings.Repeat(" ", parse_error.Offset))
+}
+
+// A flag a command accepts. An optional flag which is not given keeps whatever its destination held.
+type FlagSpec struct {
+	Name string
+	// Stores the value of the flag in its destination, or explains why it is invalid.
+	Parse    func(value string) error
+	Required bool
+}
+
+// Splits command into its tokens, removing quotes and escapes.
+func Tokenize(command string) ([]Token, error) {
+	var tokens []Token
+	var text strings.Builder
+	is_in_token := false
+	token_offset, quote, quote_offset := 0, rune(0), 0
+	is_escaped := false
+	for offset, character := range command {
+		switch {
+		case is_escaped:
+			text.WriteRune(character)
+			is_escaped = false
+		case character == '\\' && quote != '\'':
+			is_escaped = true
+		case quote != 0 && character == quote:
+			quote = 0
+		case quote != 0:
+			text.WriteRune(character)
+		case character == '"' || character == '\'':
+			quote, quote_offset = character, offset
+		case unicode.IsSpace(character):
+			if is_in_token {
+				tokens = append(tokens, Token{Text: text.String(), Offset: token_offset})
+				text.Reset()
+				is_in_token = false
+			}
+			continue
+		default:
+			text.WriteRune(character)
+		}
+		if !is_in_token {
+			is_in_token, token_offset = true, offset
+		}
+	}
+	if quote != 0 {
+		return nil, &ParseError{Command: command, Offset: quote_offset, Message: "unterminated quote"}
+	}
+	if is_escaped {
+		return nil, &ParseError{Command: command, Offset: len(command) - 1, Message: "nothing to escape at the end of the command"}
+	}
+	if is_in_token {
+		tokens = append(tokens, Token{Text: text.String(), Offset: token_offset})
+	}
+	return tokens, nil
+}
+
+// Parses the flags of command, whose first token is the name of the command, against flag_specs.
+// Fails on unknown, repeated or invalid flags and on missing required flags.
+func ParseCommand(command string, flag_specs []FlagSpec) error {
+	tokens, err := Tokenize(command)
+	if err != nil {
+		return err
+	}
+	is_given := map[string]bool{}
+	for i := 1; i < len(tokens); i++ {
+		token := tokens[i]
+		if !strings.HasPrefix(token.Text, "--") {
+			return &ParseError{Command: command, Offset: token.Offset, Message: fmt.Sprintf("expected a flag but found %q", token.Text)}
+		}
+		name, value, has_value := strings.Cut(strings.TrimPrefix(token.Text, "--"), "=")
+		flag_spec, exists := findFlagSpec(flag_specs, name)
+		if !exists {
+			return &ParseError{Command: command, Offset: token.Offset, Message: fmt.Sprintf("unknown flag --%s", name)}
+		}
+		if is_given[name] {
+			return &ParseError{Command: command, Offset: token.Offset, Message: fmt.Sprintf("--%s is given more than once", name)}
+		}
+		is_given[name] = true
+		value_offset := token.Offset
+		if !has_value {
+			if i+1 >= len(tokens) {
+				return &ParseError{Command: command, Offset: len(command), Message: fmt.Sprintf("--%s is missing its value", name)}
+			}
+			i++
+			value, value_offset = tokens[i].Text, tokens[i].Offset
+		}
+		if err := flag_spec.Parse(value); err != nil {
+			return &ParseError{Command: command, Offset: value_offset, Message: fmt.Sprintf("invalid --%s: %s", name, err)}
+		}
+	}
+	for _, flag_spec := range flag_specs {
+		if flag_spec.Required && !is_given[flag_spec.Name] {
+			return &ParseError{Command: command, Offset: len(command), Message: fmt.Sprintf("--%s is required", flag_spec.Name)}
+		}
+	}
+	return nil
+}
+
+func findFlagSpec(flag_specs []FlagSpec, name string) (FlagSpec, bool) {
+	for _, flag_spec := range flag_specs {
+		if flag_spec.Name == name {
+			return flag_spec, true
+		}
+	}
+	return FlagSpec{}, false
+}
+
+// Parses an integer in [min, max] into destination.
+func integerFlag[T ~int | ~int32](destination *T, min int64, max int64) func(string) error {
+	return func(value string) error {
+		integer, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		if integer < min || integer > max {
+			return fmt.Errorf("%d is out of the range of %s", integer, formatRange(float64(min), float64(max), false))
+		}
+		*destination = T(integer)
+		return nil
+	}
+}
+
+// Parses a number in [min, max], or in (min, max] if is_min_exclusive, into destination.
+func numberFlag(destination *float64, min float64, max float64, is_min_exclusive bool) func(string) error {
+	return func(value string) error {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(number) {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		if number < min || number > max || (is_min_exclusive && number == min) {
+			return fmt.Errorf("%s is out of the range of %s", value, formatRange(min, max, is_min_exclusive))
+		}
+		*destination = number
+		return nil
+	}
+}
+
+// Stores the value as it is in destination.
+func stringFlag(destination *string) func(string) error {
+	return func(value string) error {
+		if value == "" {
+			return fmt.Errorf("the value cannot be empty")
+		}
+		*destination = value
+		return nil
+	}
+}
+
+// Parses a grid of numbers in [min, max], as described by ParseGrid, into destination.
+func numberGridFlag(destination *[]float64, min float64, max float64) func(string) error {
+	return func(value string) error {
+		grid, err := ParseGrid(value, min, max)
+		if err != nil {
+			return err
+		}
+		*destination = grid
+		return nil
+	}
+}
+
+// Parses a grid of integers in [min, max], as described by ParseGrid, into destination.
+func integerGridFlag(destination *[]int32, min int64, max int64) func(string) error {
+	return func(value string) error {
+		grid, err := ParseGrid(value, float64(min), float64(max))
+		if err != nil {
+			return err
+		}
+		*destination = nil
+		for _, number := range grid {
+			if number != math.Trunc(number) {
+				return fmt.Errorf("%s is not an integer", strconv.FormatFloat(number, 'f', -1, 64))
+			}
+			*destination = append(*destination, int32(number))
+		}
+		return nil
+	}
+}
+
+// Parses the id of an agent, which is either a host:port address or a bare port number, into destination.
+// An address is stored in the form RetrieveAddress returns it.
+func agentIdFlag(destination *string) func(string) error {
+	return func(value string) error {
+		port := value
+		host, address_port, err := net.SplitHostPort(value)
+		if err == nil {
+			port = address_port
+		}
+		var port_number int
+		if err := integerFlag(&port_number, 1, MAX_AGENTS_NUM)(port); err != nil {
+			return fmt.Errorf("%q is neither a host:port address nor a port number", value)
+		}
+		*destination = value
+		if port != value {
+			*destination = net.JoinHostPort(host, strconv.Itoa(port_number))
+		}
+		return nil
+	}
+}
+
+// Parses one of the names listed in names, which parse maps to their value, into destination.
+func namedFlag[T ~int64](destination *T, parse func(string) (T, bool), names string) func(string) error {
+	return func(value string) error {
+		parsed_value, valid := parse(value)
+		if !valid {
+			return fmt.Errorf("%q is not one of %s", value, names)
+		}
+		*destination = parsed_value
+		return nil
+	}
+}
+
+func formatRange(min float64, max float64, is_min_exclusive bool) string {
+	opening := "["
+	if is_min_exclusive {
+		opening = "("
+	}
+	return opening + strconv.FormatFloat(min, 'f', -1, 64) + ", " + strconv.FormatFloat(max, 'f', -1, 64) + "]"
+}
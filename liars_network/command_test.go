@@ -0,0 +1,58 @@
+package liars_network
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens, err := Tokenize(`start  --strategy "random lie"	--value='1 2' --max\ value x`)
+	if err != nil {
+		t.Fatalf("Failed to tokenize: %s", err)
+	}
+	expected_tokens := []Token{{"start", 0}, {"--strategy", 7}, {"random lie", 18}, {"--value=1 2", 31}, {"--max value", 45}, {"x", 58}}
+	if len(tokens) != len(expected_tokens) {
+		t.Fatalf("Expected %v but tokenized %v", expected_tokens, tokens)
+	}
+	for i, token := range tokens {
+		if token != expected_tokens[i] {
+			t.Errorf("Expected %v but tokenized %v", expected_tokens[i], token)
+		}
+	}
+	var parse_error *ParseError
+	if _, err := Tokenize(`start --strategy "random`); !errors.As(err, &parse_error) || parse_error.Offset != 17 {
+		t.Errorf("An unterminated quote should fail at the quote, but failed with %v", err)
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	agents_num, liar_ratio, decider := 0, 0.5, EXACT_DECIDER
+	flag_specs := []FlagSpec{
+		{Name: "num-agents", Parse: integerFlag(&agents_num, 1, 10), Required: true},
+		{Name: "liar-ratio", Parse: numberFlag(&liar_ratio, 0, 1, false)},
+		{Name: "decider", Parse: namedFlag(&decider, ParseDeciderType, "exact, plurality, supermajority or bayesian")},
+	}
+	if err := ParseCommand("play  --num-agents=3 --decider 'bayesian'", flag_specs); err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	if agents_num != 3 || liar_ratio != 0.5 || decider != BAYESIAN_DECIDER {
+		t.Errorf("Did not parse the flags or keep the default correctly.")
+	}
+
+	// Every invalid command fails at the offset of the offending token.
+	invalid_commands := map[string]int{
+		"play --num-agents 11":               18,
+		"play --num-agents 3 --name Goo":     20,
+		"play --num-agents 3 --num-agents 4": 20,
+		"play --num-agents 3 4":              20,
+		"play --num-agents":                  17,
+		"play --liar-ratio 0.1":              21,
+		"play --num-agents 3 --decider=best": 20,
+	}
+	for command, offset := range invalid_commands {
+		var parse_error *ParseError
+		if err := ParseCommand(command, flag_specs); !errors.As(err, &parse_error) || parse_error.Offset != offset {
+			t.Errorf("%q should fail at offset %d, but failed with %v", command, offset, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package liars_network
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// The version of the agents.config format written by AgentsConfig.Write. Version 0 is the legacy
+// CSV format, where every row records the port number (and, optionally, the pid) of an agent. Version 1
+// numbers the agents and records a hash of their values.
+const AGENTS_CONFIG_VERSION = 2
+
+const (
+	HONEST_ROLE = "honest"
+	LIAR_ROLE   = "liar"
+)
+
+type AgentsConfig struct {
+	Version int            `json:"version"`
+	Agents  []*AgentRecord `json:"agents"`
+}
+
+type AgentRecord struct {
+	// The host:port address of the agent, which is how the agent is given to kill and to other agents.
+	Id         string    `json:"id"`
+	Host       string    `json:"host"`
+	Port       int       `json:"port"`
+	Pid        int       `json:"pid,omitempty"`
+	LaunchTime time.Time `json:"launch_time"`
+	// The hex encoded Ed25519 key which verifies the signatures of the agent over its values.
+	PublicKey string `json:"public_key,omitempty"`
+	// The address an agent process serves its metrics over, which it keeps when its value is updated.
+	MetricsAddress string `json:"metrics_address,omitempty"`
+	// Only recorded for test harnesses and for auditing games afterwards, since a client is not supposed
+	// to know which agents lie. The values themselves are recorded to the transcript rather than here, as
+	// anyone who reads agents.config could recover them from a hash by trying every value up to the max.
+	Role string `json:"role,omitempty"`
+}
+
+func NewAgentsConfig() *AgentsConfig {
+	return &AgentsConfig{Version: AGENTS_CONFIG_VERSION}
+}
+
+// The address to dial to reach the agent.
+func (record *AgentRecord) Address() string {
+	return net.JoinHostPort(record.Host, strconv.Itoa(record.Port))
+}
+
+// Records whether the agent is honest.
+func (record *AgentRecord) Audit(agent_value int32, network_value int32) {
+	record.Role = LIAR_ROLE
+	if agent_value == network_value {
+		record.Role = HONEST_ROLE
+	}
+}
+
+// Reads a record written by any version, including version 1 and the transcripts of its time, whose
+// agents are numbered rather than identified by their addresses.
+func (record *AgentRecord) UnmarshalJSON(content []byte) error {
+	type agentRecord AgentRecord
+	numbered_record := struct {
+		*agentRecord
+		Id json.RawMessage `json:"id"`
+	}{agentRecord: (*agentRecord)(record)}
+	if err := json.Unmarshal(content, &numbered_record); err != nil {
+		return err
+	}
+	record.Id = record.Address()
+	return nil
+}
+
+// Appends a record to the config, identified by the address of the agent.
+func (config *AgentsConfig) Add(record *AgentRecord) {
+	record.Id = record.Address()
+	config.Agents = append(config.Agents, record)
+}
+
+// Finds the record of the agent reached through address, or nil if there is no such agent.
+func (config *AgentsConfig) Find(address string) *AgentRecord {
+	for _, record := range config.Agents {
+		if record.Address() == address {
+			return record
+		}
+	}
+	return nil
+}
+
+// Reads agents.config from path. Both the current JSON format and the legacy CSV format are accepted;
+// a legacy config is migrated to the current version, with every agent assumed to be on localhost.
+func ReadAgentsConfig(path string) (*AgentsConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(content); len(trimmed) != 0 && trimmed[0] != '{' {
+		return readLegacyAgentsConfig(content)
+	}
+	config := NewAgentsConfig()
+	if err := json.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if config.Version > AGENTS_CONFIG_VERSION {
+		return nil, fmt.Errorf("%s is of version %d, but only versions up to %d are supported",
+			path, config.Version, AGENTS_CONFIG_VERSION)
+	}
+	return config, nil
+}
+
+func readLegacyAgentsConfig(content []byte) (*AgentsConfig, error) {
+	config_reader := csv.NewReader(bytes.NewReader(content))
+	// Rows only record the port number in the earliest versions.
+	config_reader.FieldsPerRecord = -1
+	rows, err := config_reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	config := NewAgentsConfig()
+	for _, row := range rows {
+		record := &AgentRecord{Host: "localhost"}
+		if record.Port, err = strconv.Atoi(row[0]); err != nil {
+			return nil, fmt.Errorf("failed to parse port number %q: %w", row[0], err)
+		}
+		if len(row) > 1 {
+			if record.Pid, err = strconv.Atoi(row[1]); err != nil {
+				return nil, fmt.Errorf("failed to parse pid %q: %w", row[1], err)
+			}
+		}
+		config.Add(record)
+	}
+	return config, nil
+}
+
+// Writes the config to path in the current format. The config is written to a temporary file
+// which then replaces path, so readers never see a partially written config.
+func (config *AgentsConfig) Write(path string) error {
+	config.Version = AGENTS_CONFIG_VERSION
+	content, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp_file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp_file.Name())
+	if _, err := temp_file.Write(append(content, '\n')); err != nil {
+		temp_file.Close()
+		return err
+	}
+	if err := temp_file.Chmod(0644); err != nil {
+		temp_file.Close()
+		return err
+	}
+	if err := temp_file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp_file.Name(), path)
+}
+
+// Removes the record of the agent reached through address. Returns false if there is no such agent.
+func (config *AgentsConfig) Remove(address string) bool {
+	for i, record := range config.Agents {
+		if record.Address() == address {
+			config.Agents = append(config.Agents[:i], config.Agents[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
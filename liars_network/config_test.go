@@ -0,0 +1,80 @@
+package liars_network
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAgentsConfig(t *testing.T) {
+	config_path := filepath.Join(t.TempDir(), "agents.config")
+	config := NewAgentsConfig()
+	honest_record := &AgentRecord{Host: "localhost", Port: 4000, Pid: 10, LaunchTime: time.Now()}
+	honest_record.Audit(3, 3)
+	liar_record := &AgentRecord{Host: "10.0.0.2", Port: 4001, Pid: 11, LaunchTime: time.Now()}
+	liar_record.Audit(5, 3)
+	config.Add(honest_record)
+	config.Add(liar_record)
+	if err := config.Write(config_path); err != nil {
+		t.Fatalf("Failed to write agents.config: %s", err)
+	}
+
+	read_config, err := ReadAgentsConfig(config_path)
+	if err != nil {
+		t.Fatalf("Failed to read agents.config: %s", err)
+	}
+	if read_config.Version != AGENTS_CONFIG_VERSION || len(read_config.Agents) != 2 {
+		t.Fatalf("%+v should contain 2 agents", read_config)
+	}
+	if record := read_config.Find("10.0.0.2:4001"); record == nil || record.Id != "10.0.0.2:4001" || record.Role != LIAR_ROLE ||
+		record.Address() != "10.0.0.2:4001" {
+		t.Errorf("Did not read the record of the liar correctly: %+v", record)
+	}
+	if record := read_config.Find("localhost:4000"); record == nil || record.Id != "localhost:4000" || record.Role != HONEST_ROLE {
+		t.Errorf("Did not read the record of the honest agent correctly: %+v", record)
+	}
+	if read_config.Find("10.0.0.2:4002") != nil {
+		t.Errorf("There is no agent on port 4002")
+	}
+}
+
+func TestReadNumberedAgentsConfig(t *testing.T) {
+	config_path := filepath.Join(t.TempDir(), "agents.config")
+	numbered_config := `{"version": 1, "agents": [{"id": 1, "host": "localhost", "port": 4000, "role": "liar", "value_hash": "ab"},
+		{"id": 2, "host": "10.0.0.2", "port": 4001}]}`
+	if err := os.WriteFile(config_path, []byte(numbered_config), 0644); err != nil {
+		t.Fatalf("Failed to write agents.config: %s", err)
+	}
+	config, err := ReadAgentsConfig(config_path)
+	if err != nil {
+		t.Fatalf("Failed to read the agents.config of version 1: %s", err)
+	}
+	// The agents are identified by their addresses from then on, which is what kill accepts.
+	if len(config.Agents) != 2 || config.Agents[0].Id != "localhost:4000" || config.Agents[0].Role != LIAR_ROLE ||
+		config.Agents[1].Id != "10.0.0.2:4001" {
+		t.Errorf("Did not migrate the agents.config of version 1 correctly: %+v", config.Agents)
+	}
+}
+
+func TestReadLegacyAgentsConfig(t *testing.T) {
+	config_path := filepath.Join(t.TempDir(), "agents.config")
+	if err := os.WriteFile(config_path, []byte("4000\n4001,11\n"), 0644); err != nil {
+		t.Fatalf("Failed to write agents.config: %s", err)
+	}
+	config, err := ReadAgentsConfig(config_path)
+	if err != nil {
+		t.Fatalf("Failed to read the legacy agents.config: %s", err)
+	}
+	if len(config.Agents) != 2 || config.Agents[0].Port != 4000 || config.Agents[1].Pid != 11 ||
+		config.Agents[1].Address() != "localhost:4001" {
+		t.Errorf("Did not migrate the legacy agents.config correctly: %+v", config.Agents)
+	}
+
+	if err := os.WriteFile(config_path, []byte("not-a-port\n"), 0644); err != nil {
+		t.Fatalf("Failed to write agents.config: %s", err)
+	}
+	if _, err := ReadAgentsConfig(config_path); err == nil {
+		t.Errorf("A legacy agents.config with an invalid port should fail to be read")
+	}
+}
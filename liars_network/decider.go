@@ -0,0 +1,218 @@
+package liars_network
+
+import "math"
+
+type DeciderType int64
+
+const (
+	// The network value is the unique value whose frequency matches the number of honest agents.
+	EXACT_DECIDER DeciderType = iota
+	// The network value is the unique most frequent value.
+	PLURALITY_DECIDER
+	// The network value is the most frequent value, as long as its share of the responses reaches a threshold.
+	SUPERMAJORITY_DECIDER
+	// The network value is the value with the highest posterior probability given the liar ratio and max value.
+	BAYESIAN_DECIDER
+)
+
+// The share of the responses SUPERMAJORITY_DECIDER requires when no threshold is given.
+const DEFAULT_SUPERMAJORITY_THRESHOLD = 2.0 / 3.0
+
+var decider_names = map[string]DeciderType{
+	"exact":         EXACT_DECIDER,
+	"plurality":     PLURALITY_DECIDER,
+	"supermajority": SUPERMAJORITY_DECIDER,
+	"bayesian":      BAYESIAN_DECIDER,
+}
+
+// Maps the name used in the play/playexpert command to its DeciderType.
+func ParseDeciderType(name string) (DeciderType, bool) {
+	decider_type, exists := decider_names[name]
+	return decider_type, exists
+}
+
+func (decider_type DeciderType) String() string {
+	for name, other_type := range decider_names {
+		if other_type == decider_type {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// What the client knows or assumes about the network, besides the responses it gathered.
+type NetworkAssumptions struct {
+	// The number of honest agents in the whole network.
+	HonestAgentsNum int
+	// The number of queried agents which did not respond.
+	AbstentionsNum int
+	// Whether only a random sample of the network was queried.
+	IsPartialSample bool
+	LiarRatio       float64
+	MaxValue        int32
+	// The share of the responses SUPERMAJORITY_DECIDER requires.
+	Threshold float64
+}
+
+// The network value a Decider settles on, along with how confident it is about it.
+type Decision struct {
+	Value   int32
+	Decided bool
+	// In [0, 1]. Always 0 if no value is decided.
+	Confidence float64
+}
+
+// A Decider infers the network value from the responses gathered from the agents.
+type Decider interface {
+	Decide(responses []int32) Decision
+}
+
+func NewDecider(decider_type DeciderType, assumptions NetworkAssumptions) Decider {
+	switch decider_type {
+	case PLURALITY_DECIDER:
+		return &PluralityDecider{}
+	case SUPERMAJORITY_DECIDER:
+		return &SupermajorityDecider{threshold: assumptions.Threshold}
+	case BAYESIAN_DECIDER:
+		return &BayesianDecider{liar_ratio: assumptions.LiarRatio, max_value: assumptions.MaxValue}
+	default:
+		return &ExactDecider{assumptions: assumptions}
+	}
+}
+
+type ExactDecider struct {
+	assumptions NetworkAssumptions
+}
+
+// The exact rule either knows the network value for sure or does not know it at all, so the confidence
+// is always 1 when a value is decided.
+func (decider *ExactDecider) Decide(responses []int32) Decision {
+	var network_value int32
+	var exists bool
+	if decider.assumptions.IsPartialSample {
+		network_value, exists = FindNetworkValueFromSample(responses, decider.assumptions.LiarRatio)
+	} else {
+		network_value, exists = FindNetworkValueWithAbstentions(responses, decider.assumptions.HonestAgentsNum,
+			decider.assumptions.AbstentionsNum)
+	}
+	if !exists {
+		return Decision{}
+	}
+	return Decision{Value: network_value, Decided: true, Confidence: 1}
+}
+
+type PluralityDecider struct{}
+
+// The confidence is the share of the responses which agree with the decided value.
+func (decider *PluralityDecider) Decide(responses []int32) Decision {
+	network_value, frequency, is_unique := findMostFrequent(responses)
+	if !is_unique {
+		return Decision{}
+	}
+	return Decision{Value: network_value, Decided: true, Confidence: float64(frequency) / float64(len(responses))}
+}
+
+type SupermajorityDecider struct {
+	threshold float64
+}
+
+// The confidence is the share of the responses which agree with the decided value.
+func (decider *SupermajorityDecider) Decide(responses []int32) Decision {
+	network_value, frequency, is_unique := findMostFrequent(responses)
+	share := float64(frequency) / float64(len(responses))
+	if !is_unique || share < decider.threshold {
+		return Decision{}
+	}
+	return Decision{Value: network_value, Decided: true, Confidence: share}
+}
+
+type BayesianDecider struct {
+	liar_ratio float64
+	max_value  int32
+}
+
+// Every value in [1, max_value] is equally likely to be the network value a priori. Given the network
+// value v, an agent is honest and answers v with probability 1 - liar_ratio, or lies and answers any
+// other value in [1, max_value] uniformly. The confidence is the posterior probability of the decided value.
+func (decider *BayesianDecider) Decide(responses []int32) Decision {
+	element_to_frequency_map := map[int32]int{}
+	for _, response := range responses {
+		element_to_frequency_map[response]++
+	}
+	// The log likelihood of the responses if the network value were answered by honest_num of them and
+	// the liars picked their values out of lie_choices_num values.
+	log_likelihood := func(honest_num int, lie_choices_num float64) float64 {
+		lie_log_probability := math.Inf(-1)
+		if lie_choices_num > 0 {
+			lie_log_probability = math.Log(decider.liar_ratio) - math.Log(lie_choices_num)
+		}
+		return logTimes(honest_num, math.Log(1-decider.liar_ratio)) + logTimes(len(responses)-honest_num, lie_log_probability)
+	}
+
+	log_likelihoods := map[int32]float64{}
+	// Every value in [1, max_value] which no agent answered with shares the same likelihood.
+	unseen_num := float64(decider.max_value)
+	for element, frequency := range element_to_frequency_map {
+		if element >= 1 && element <= decider.max_value {
+			log_likelihoods[element] = log_likelihood(frequency, float64(decider.max_value-1))
+			unseen_num--
+		} else {
+			// The network value is outside [1, max_value], so the liars may pick any value in it.
+			log_likelihoods[element] = log_likelihood(frequency, float64(decider.max_value))
+		}
+	}
+	unseen_log_likelihood := log_likelihood(0, float64(decider.max_value-1))
+
+	best_log_likelihood := math.Inf(-1)
+	var network_value int32
+	var is_unique bool
+	for element, element_log_likelihood := range log_likelihoods {
+		if element_log_likelihood > best_log_likelihood {
+			best_log_likelihood, network_value, is_unique = element_log_likelihood, element, true
+		} else if element_log_likelihood == best_log_likelihood {
+			is_unique = false
+		}
+	}
+	if unseen_num > 0 && unseen_log_likelihood >= best_log_likelihood {
+		is_unique = false
+	}
+	if !is_unique || math.IsInf(best_log_likelihood, -1) {
+		return Decision{}
+	}
+	// Normalizes the likelihoods relative to the best one, to avoid underflows.
+	evidence := 0.0
+	for _, other_log_likelihood := range log_likelihoods {
+		evidence += math.Exp(other_log_likelihood - best_log_likelihood)
+	}
+	if unseen_num > 0 {
+		evidence += unseen_num * math.Exp(unseen_log_likelihood-best_log_likelihood)
+	}
+	return Decision{Value: network_value, Decided: true, Confidence: 1 / evidence}
+}
+
+// Returns times * log_value, where 0 * log(0) is 0 rather than NaN.
+func logTimes(times int, log_value float64) float64 {
+	if times == 0 {
+		return 0
+	}
+	return float64(times) * log_value
+}
+
+// Returns the most frequent element, its frequency and whether no other element is as frequent.
+func findMostFrequent(elements []int32) (int32, int, bool) {
+	element_to_frequency_map := map[int32]int{}
+	for _, element := range elements {
+		element_to_frequency_map[element]++
+	}
+	var most_frequent_element int32
+	var highest_frequency int
+	var is_unique bool
+	for element, frequency := range element_to_frequency_map {
+		if frequency > highest_frequency {
+			most_frequent_element, highest_frequency, is_unique = element, frequency, true
+		} else if frequency == highest_frequency {
+			is_unique = false
+		}
+	}
+	return most_frequent_element, highest_frequency, is_unique
+}
@@ -0,0 +1,62 @@
+package liars_network
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExactDecider(t *testing.T) {
+	decider := NewDecider(EXACT_DECIDER, NetworkAssumptions{HonestAgentsNum: 3, AbstentionsNum: 1})
+	if decision := decider.Decide([]int32{4, 4, 7, 9}); !decision.Decided || decision.Value != 4 || decision.Confidence != 1 {
+		t.Errorf("Should decide 4 with full confidence, got %+v", decision)
+	}
+	if decision := decider.Decide([]int32{4, 4, 7, 7}); decision.Decided || decision.Confidence != 0 {
+		t.Errorf("Should not decide any value when 4 and 7 tie, got %+v", decision)
+	}
+	sample_decider := NewDecider(EXACT_DECIDER, NetworkAssumptions{IsPartialSample: true, LiarRatio: 0.25})
+	if decision := sample_decider.Decide([]int32{4, 4, 7}); !decision.Decided || decision.Value != 4 {
+		t.Errorf("Should decide 4 from the partial sample, got %+v", decision)
+	}
+}
+
+func TestPluralityAndSupermajorityDecider(t *testing.T) {
+	responses := []int32{4, 4, 4, 7, 9, 9}
+	if decision := NewDecider(PLURALITY_DECIDER, NetworkAssumptions{}).Decide(responses); !decision.Decided ||
+		decision.Value != 4 || decision.Confidence != 0.5 {
+		t.Errorf("Should decide 4 with confidence 0.5, got %+v", decision)
+	}
+	if decision := NewDecider(PLURALITY_DECIDER, NetworkAssumptions{}).Decide([]int32{4, 9}); decision.Decided {
+		t.Errorf("Should not decide any value when 4 and 9 tie, got %+v", decision)
+	}
+	if decision := NewDecider(SUPERMAJORITY_DECIDER, NetworkAssumptions{Threshold: 0.5}).Decide(responses); !decision.Decided ||
+		decision.Value != 4 {
+		t.Errorf("Should decide 4 when half of the responses are required, got %+v", decision)
+	}
+	if decision := NewDecider(SUPERMAJORITY_DECIDER, NetworkAssumptions{Threshold: DEFAULT_SUPERMAJORITY_THRESHOLD}).Decide(responses); decision.Decided {
+		t.Errorf("Should not decide any value when two thirds of the responses are required, got %+v", decision)
+	}
+}
+
+func TestBayesianDecider(t *testing.T) {
+	decider := NewDecider(BAYESIAN_DECIDER, NetworkAssumptions{LiarRatio: 0.3, MaxValue: 10})
+	decision := decider.Decide([]int32{4, 4, 4, 4, 4, 4, 4, 2, 9, 1})
+	if !decision.Decided || decision.Value != 4 || decision.Confidence < 0.99 || decision.Confidence > 1 {
+		t.Errorf("Should decide 4 with a high confidence, got %+v", decision)
+	}
+	// A single response is weak evidence.
+	decision = decider.Decide([]int32{4})
+	if !decision.Decided || decision.Value != 4 || decision.Confidence > 0.9 {
+		t.Errorf("Should decide 4 with a lower confidence, got %+v", decision)
+	}
+	if decision := decider.Decide([]int32{4, 4, 7, 7}); decision.Decided {
+		t.Errorf("Should not decide any value when 4 and 7 are equally likely, got %+v", decision)
+	}
+	if decision := decider.Decide([]int32{}); decision.Decided {
+		t.Errorf("Should not decide any value without any response, got %+v", decision)
+	}
+	// With no liars at all, a single honest response is conclusive.
+	honest_decider := NewDecider(BAYESIAN_DECIDER, NetworkAssumptions{LiarRatio: 0, MaxValue: 10})
+	if decision := honest_decider.Decide([]int32{4}); !decision.Decided || math.Abs(decision.Confidence-1) > 1e-9 {
+		t.Errorf("Should decide 4 with full confidence, got %+v", decision)
+	}
+}
@@ -0,0 +1,194 @@
+package liars_network
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// How long an agent waits for a neighbor to gossip back, per hop the request is still relayed, unless
+// the request says otherwise.
+const GOSSIP_HOP_TIMEOUT = 2 * time.Second
+
+// The longest gossiping for at most hops hops takes, waiting hop_timeout per hop. Every agent relaying
+// the request waits for its neighbors one hop less than it is waited for itself, so that it still has
+// time to respond.
+func GossipTimeout(hops int, hop_timeout time.Duration) time.Duration {
+	return time.Duration(hops+1) * hop_timeout
+}
+
+// Sets the neighbors of the agent at address, which it gossips with, waiting at most timeout.
+func SetAgentNeighbors(address string, neighbors []string, timeout time.Duration) error {
+	conn, err := dialAgent(address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, err = NewLieServiceClient(conn).SetNeighbors(ctx, &SetNeighborsRequest{Neighbors: neighbors})
+	return err
+}
+
+func (agent *Agent) SetNeighbors(ctx context.Context, request *SetNeighborsRequest) (*SetNeighborsResponse, error) {
+	agent.neighbors_mutex.Lock()
+	defer agent.neighbors_mutex.Unlock()
+	agent.neighbors = request.GetNeighbors()
+	return &SetNeighborsResponse{}, nil
+}
+
+// A gossip which reached the agent, identified by the nonce of the query it answers.
+type seenGossip struct {
+	// The most hops the gossip was still to be relayed for when it reached the agent.
+	hops int
+	// When the gossip is over, after which it is forgotten.
+	expiry time.Time
+}
+
+func (agent *Agent) Gossip(ctx context.Context, request *GossipRequest) (*GossipResponse, error) {
+	hop_timeout := requestTimeout(request.GetHopTimeoutMs(), GOSSIP_HOP_TIMEOUT)
+	// The same gossip reaches the agent through every path to it, but only needs relaying again when it
+	// can go further than before.
+	if !agent.seeGossip(request.GetNonce(), int(request.GetHops()), hop_timeout) {
+		return &GossipResponse{}, nil
+	}
+	return &GossipResponse{Records: agent.gossip(int(request.GetHops()), request.GetRoute(), request.GetNonce(), hop_timeout)}, nil
+}
+
+// Records that the gossip of nonce reached the agent with hops hops left, and returns whether the agent
+// has not relayed it as far yet. A gossip without a nonce cannot be told apart from another, so it is
+// always relayed.
+func (agent *Agent) seeGossip(nonce []byte, hops int, hop_timeout time.Duration) bool {
+	if len(nonce) == 0 {
+		return true
+	}
+	agent.seen_gossip_mutex.Lock()
+	defer agent.seen_gossip_mutex.Unlock()
+	now := time.Now()
+	if agent.seen_gossip == nil {
+		agent.seen_gossip = map[string]*seenGossip{}
+	}
+	seen, exists := agent.seen_gossip[string(nonce)]
+	if exists && seen.hops >= hops {
+		return false
+	}
+	if !exists {
+		for other_nonce, other_seen := range agent.seen_gossip {
+			if now.After(other_seen.expiry) {
+				delete(agent.seen_gossip, other_nonce)
+			}
+		}
+	}
+	agent.seen_gossip[string(nonce)] = &seenGossip{hops: hops, expiry: now.Add(GossipTimeout(hops, hop_timeout))}
+	return true
+}
+
+// Answers an expert query by gossiping through the neighbors rather than by contacting the other agents
+// directly. The other agents which are not within hops hops of the agent are unreachable.
+func (agent *Agent) gossipLieQuery(lie_request *LieRequest) *LieResponse {
+	agent_id_to_record_map := map[string]*GossipRecord{}
+	hop_timeout := requestTimeout(lie_request.GetQueryTimeoutMs(), GOSSIP_HOP_TIMEOUT)
+	agent.seeGossip(lie_request.GetNonce(), int(lie_request.GetHops()), hop_timeout)
+	for _, record := range agent.gossip(int(lie_request.GetHops()), nil, lie_request.GetNonce(), hop_timeout) {
+		agent_id_to_record_map[record.AgentId] = record
+	}
+	self_record := agent_id_to_record_map[agent.RetrieveAddress()]
+	lie_response := &LieResponse{AgentValue: self_record.Value, Signature: self_record.Signature}
+	for _, agent_id := range lie_request.GetOtherAgentIds() {
+		record, exists := agent_id_to_record_map[AgentAddress(agent_id)]
+		if !exists {
+			lie_response.UnreachableAgentIds = append(lie_response.UnreachableAgentIds, agent_id)
+			continue
+		}
+		lie_response.CollectedAgentValues = append(lie_response.CollectedAgentValues, record.Value)
+		lie_response.GossipRecords = append(lie_response.GossipRecords, record)
+		lie_response.SignedValues = append(lie_response.SignedValues,
+			&SignedValue{AgentId: agent_id, Value: record.Value, Signature: record.Signature})
+	}
+	agent_fan_outs_total.Inc(agent.RetrieveAddress())
+	agent_errors_total.Add(float64(len(lie_response.UnreachableAgentIds)), agent.RetrieveAddress())
+	return lie_response
+}
+
+// Collects the values of the agents within hops hops of the agent, including its own, by relaying the
+// request to every neighbor which is not on route. Of the values answered by the same agent, only the
+// one which went through the fewest agents is kept. A tampering agent may alter the values it relays,
+// but neither their provenance nor their signatures. The neighbors are waited for hop_timeout per hop.
+func (agent *Agent) gossip(hops int, route []string, nonce []byte, hop_timeout time.Duration) []*GossipRecord {
+	self := agent.RetrieveAddress()
+	agent_value := agent.answer()
+	records := []*GossipRecord{{AgentId: self, Value: agent_value, Path: []string{self}, Signature: agent.sign(agent_value, nonce)}}
+	if hops <= 0 {
+		return records
+	}
+	route = append(append([]string{}, route...), self)
+	agent.neighbors_mutex.Lock()
+	neighbors := agent.neighbors
+	agent.neighbors_mutex.Unlock()
+
+	var records_mutex sync.Mutex
+	var wait_group sync.WaitGroup
+	for _, neighbor := range neighbors {
+		if containsAddress(route, neighbor) {
+			continue
+		}
+		wait_group.Add(1)
+		go func(neighbor string) {
+			defer wait_group.Done()
+			relayed_records, err := gossipWith(neighbor, &GossipRequest{Hops: int32(hops - 1), Route: route, Nonce: nonce,
+				HopTimeoutMs: hop_timeout.Milliseconds()}, hop_timeout)
+			// A neighbor which cannot be reached relays nothing.
+			if err != nil {
+				return
+			}
+			records_mutex.Lock()
+			defer records_mutex.Unlock()
+			for _, record := range relayed_records {
+				record.Value = agent.relay(record.Value, agent_value)
+				record.Path = append(record.Path, self)
+				records = append(records, record)
+			}
+		}(neighbor)
+	}
+	wait_group.Wait()
+	return shortestGossipRecords(records)
+}
+
+func gossipWith(address string, request *GossipRequest, hop_timeout time.Duration) ([]*GossipRecord, error) {
+	conn, err := dialAgent(address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), GossipTimeout(int(request.GetHops()), hop_timeout))
+	defer cancel()
+	response, err := NewLieServiceClient(conn).Gossip(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return response.GetRecords(), nil
+}
+
+// Keeps the record with the shortest path for every agent, sorted by the length of the path and then by agent.
+func shortestGossipRecords(records []*GossipRecord) []*GossipRecord {
+	agent_id_to_record_map := map[string]*GossipRecord{}
+	for _, record := range records {
+		shortest, exists := agent_id_to_record_map[record.AgentId]
+		if !exists || len(record.Path) < len(shortest.Path) {
+			agent_id_to_record_map[record.AgentId] = record
+		}
+	}
+	var shortest_records []*GossipRecord
+	for _, record := range agent_id_to_record_map {
+		shortest_records = append(shortest_records, record)
+	}
+	sort.Slice(shortest_records, func(i, j int) bool {
+		if len(shortest_records[i].Path) != len(shortest_records[j].Path) {
+			return len(shortest_records[i].Path) < len(shortest_records[j].Path)
+		}
+		return shortest_records[i].AgentId < shortest_records[j].AgentId
+	})
+	return shortest_records
+}
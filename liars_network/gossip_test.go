@@ -0,0 +1,79 @@
+package liars_network
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGossipLieQuery(t *testing.T) {
+	// A ring of 5 agents, where the agent answering with i is at index i.
+	var addresses []string
+	for value := int32(0); value < 5; value++ {
+		addresses = append(addresses, launchTestAgent(t, value))
+	}
+	ring, _ := BuildTopology(RING_TOPOLOGY, len(addresses), 2, 0)
+	for i, adjacent := range ring {
+		var neighbors []string
+		for _, j := range adjacent {
+			neighbors = append(neighbors, addresses[j])
+		}
+		if err := SetAgentNeighbors(addresses[i], neighbors, time.Second); err != nil {
+			t.Fatalf("Failed to set the neighbors of agent %d: %s", i, err)
+		}
+	}
+
+	// Within one hop, the proxy agent only reaches its two neighbors.
+	result := QueryAgent(addresses[0], &LieRequest{ExpertMode: true, OtherAgentIds: addresses[1:], Hops: 1}, GossipTimeout(1, GOSSIP_HOP_TIMEOUT))
+	if result.Err != nil {
+		t.Fatalf("Failed to query the proxy agent: %s", result.Err)
+	}
+	if len(result.Response.GossipRecords) != 2 || len(result.Response.UnreachableAgentIds) != 2 {
+		t.Errorf("The proxy agent should only reach its neighbors within one hop, got %+v", result.Response)
+	}
+
+	result = QueryAgent(addresses[0], &LieRequest{ExpertMode: true, OtherAgentIds: addresses[1:], Hops: 2}, GossipTimeout(2, GOSSIP_HOP_TIMEOUT))
+	if result.Err != nil {
+		t.Fatalf("Failed to query the proxy agent: %s", result.Err)
+	}
+	if len(result.Response.CollectedAgentValues) != 4 || len(result.Response.UnreachableAgentIds) != 0 {
+		t.Errorf("The proxy agent should reach every agent within two hops, got %+v", result.Response)
+	}
+	for _, record := range result.Response.GossipRecords {
+		if record.Value != 2 {
+			continue
+		}
+		expected_path := []string{addresses[2], addresses[1], addresses[0]}
+		if len(record.Path) != len(expected_path) {
+			t.Fatalf("The value of agent 2 should be relayed through %v, got %v", expected_path, record.Path)
+		}
+		for i := range expected_path {
+			if record.Path[i] != expected_path[i] {
+				t.Errorf("The value of agent 2 should be relayed through %v, got %v", expected_path, record.Path)
+			}
+		}
+	}
+}
+
+func TestRepeatedGossip(t *testing.T) {
+	// The agent has no neighbors, so it relays nothing but its own record.
+	address, nonce := launchTestAgent(t, 3), NewNonce()
+	gossip := func(hops int32) int {
+		records, err := gossipWith(address, &GossipRequest{Hops: hops, Nonce: nonce}, time.Second)
+		if err != nil {
+			t.Fatalf("Failed to gossip with the agent: %s", err)
+		}
+		return len(records)
+	}
+	if records_num := gossip(1); records_num != 1 {
+		t.Fatalf("The agent should relay the gossip the first time, got %d records", records_num)
+	}
+	if records_num := gossip(1); records_num != 0 {
+		t.Errorf("The agent should drop the gossip it already relayed, got %d records", records_num)
+	}
+	if records_num := gossip(2); records_num != 1 {
+		t.Errorf("The agent should relay the gossip again when it can go further, got %d records", records_num)
+	}
+	if records_num := gossip(0); records_num != 0 {
+		t.Errorf("The agent should drop the gossip which cannot go further than before, got %d records", records_num)
+	}
+}
@@ -26,8 +26,18 @@ type LieRequest struct {
 	unknownFields protoimpl.UnknownFields
 
 	// if true, then this request is sent from playexpert command.
-	ExpertMode    bool     `protobuf:"varint,1,opt,name=expert_mode,json=expertMode,proto3" json:"expert_mode,omitempty"`
+	ExpertMode bool `protobuf:"varint,1,opt,name=expert_mode,json=expertMode,proto3" json:"expert_mode,omitempty"`
+	// host:port addresses of the agents to collect values from. A bare port number refers to
+	// an agent on the same machine.
 	OtherAgentIds []string `protobuf:"bytes,2,rep,name=other_agent_ids,json=otherAgentIds,proto3" json:"other_agent_ids,omitempty"`
+	// if non-zero, then the proxy agent reaches the other agents by gossiping through its neighbors
+	// for at most this many hops, rather than by contacting them directly.
+	Hops int32 `protobuf:"varint,3,opt,name=hops,proto3" json:"hops,omitempty"`
+	// random bytes which every agent signs along with its value, so that signatures cannot be replayed.
+	Nonce []byte `protobuf:"bytes,4,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	// how long the proxy agent waits for the response of a single agent, or for a single hop when
+	// gossiping, in milliseconds. If zero, then the proxy agent waits for its default timeout.
+	QueryTimeoutMs int64 `protobuf:"varint,5,opt,name=query_timeout_ms,json=queryTimeoutMs,proto3" json:"query_timeout_ms,omitempty"`
 }
 
 func (x *LieRequest) Reset() {
@@ -76,32 +86,495 @@ func (x *LieRequest) GetOtherAgentIds() []string {
 	return nil
 }
 
+func (x *LieRequest) GetHops() int32 {
+	if x != nil {
+		return x.Hops
+	}
+	return 0
+}
+
+func (x *LieRequest) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+func (x *LieRequest) GetQueryTimeoutMs() int64 {
+	if x != nil {
+		return x.QueryTimeoutMs
+	}
+	return 0
+}
+
 type LieResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	AgentValue           int32   `protobuf:"varint,1,opt,name=agent_value,json=agentValue,proto3" json:"agent_value,omitempty"`
-	CollectedAgentValues []int32 `protobuf:"varint,2,rep,packed,name=collected_agent_values,json=collectedAgentValues,proto3" json:"collected_agent_values,omitempty"`
+	AgentValue           int32   `protobuf:"varint,1,opt,name=agent_value,json=agentValue,proto3" json:"agent_value,omitempty"`
+	CollectedAgentValues []int32 `protobuf:"varint,2,rep,packed,name=collected_agent_values,json=collectedAgentValues,proto3" json:"collected_agent_values,omitempty"`
+	// ids of the agents which the proxy agent failed to collect values from.
+	UnreachableAgentIds []string `protobuf:"bytes,3,rep,name=unreachable_agent_ids,json=unreachableAgentIds,proto3" json:"unreachable_agent_ids,omitempty"`
+	// where every collected value came from, if the values were collected by gossiping.
+	GossipRecords []*GossipRecord `protobuf:"bytes,4,rep,name=gossip_records,json=gossipRecords,proto3" json:"gossip_records,omitempty"`
+	// signature of the agent over its id, agent_value and the nonce.
+	Signature []byte `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	// the collected values along with the signatures of the agents which answered them, in the same
+	// order as collected_agent_values.
+	SignedValues []*SignedValue `protobuf:"bytes,6,rep,name=signed_values,json=signedValues,proto3" json:"signed_values,omitempty"`
+}
+
+func (x *LieResponse) Reset() {
+	*x = LieResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_liars_network_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LieResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LieResponse) ProtoMessage() {}
+
+func (x *LieResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_liars_network_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LieResponse.ProtoReflect.Descriptor instead.
+func (*LieResponse) Descriptor() ([]byte, []int) {
+	return file_liars_network_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LieResponse) GetAgentValue() int32 {
+	if x != nil {
+		return x.AgentValue
+	}
+	return 0
+}
+
+func (x *LieResponse) GetCollectedAgentValues() []int32 {
+	if x != nil {
+		return x.CollectedAgentValues
+	}
+	return nil
+}
+
+func (x *LieResponse) GetUnreachableAgentIds() []string {
+	if x != nil {
+		return x.UnreachableAgentIds
+	}
+	return nil
+}
+
+func (x *LieResponse) GetGossipRecords() []*GossipRecord {
+	if x != nil {
+		return x.GossipRecords
+	}
+	return nil
+}
+
+func (x *LieResponse) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *LieResponse) GetSignedValues() []*SignedValue {
+	if x != nil {
+		return x.SignedValues
+	}
+	return nil
+}
+
+type SignedValue struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// host:port address of the agent which answered the value.
+	AgentId string `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Value   int32  `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+	// signature of the agent over its id, the value and the nonce of the request.
+	Signature []byte `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (x *SignedValue) Reset() {
+	*x = SignedValue{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_liars_network_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SignedValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignedValue) ProtoMessage() {}
+
+func (x *SignedValue) ProtoReflect() protoreflect.Message {
+	mi := &file_liars_network_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignedValue.ProtoReflect.Descriptor instead.
+func (*SignedValue) Descriptor() ([]byte, []int) {
+	return file_liars_network_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SignedValue) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *SignedValue) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *SignedValue) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+// A value gossiped through the network, along with its provenance.
+type GossipRecord struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// host:port address of the agent which answered the value.
+	AgentId string `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Value   int32  `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+	// host:port addresses of every agent the value went through, starting from the agent which
+	// answered it.
+	Path []string `protobuf:"bytes,3,rep,name=path,proto3" json:"path,omitempty"`
+	// signature of the agent which answered the value over its id, the value and the nonce of the request.
+	Signature []byte `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (x *GossipRecord) Reset() {
+	*x = GossipRecord{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_liars_network_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GossipRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GossipRecord) ProtoMessage() {}
+
+func (x *GossipRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_liars_network_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GossipRecord.ProtoReflect.Descriptor instead.
+func (*GossipRecord) Descriptor() ([]byte, []int) {
+	return file_liars_network_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GossipRecord) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *GossipRecord) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *GossipRecord) GetPath() []string {
+	if x != nil {
+		return x.Path
+	}
+	return nil
+}
+
+func (x *GossipRecord) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+type GossipRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// how many more times the request is relayed to the neighbors.
+	Hops int32 `protobuf:"varint,1,opt,name=hops,proto3" json:"hops,omitempty"`
+	// host:port addresses of the agents which relayed the request so far, which it is not relayed back to.
+	Route []string `protobuf:"bytes,2,rep,name=route,proto3" json:"route,omitempty"`
+	Nonce []byte   `protobuf:"bytes,3,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	// how long every agent waits for a neighbor to gossip back, per hop the request is still relayed,
+	// in milliseconds. If zero, then GOSSIP_HOP_TIMEOUT.
+	HopTimeoutMs int64 `protobuf:"varint,4,opt,name=hop_timeout_ms,json=hopTimeoutMs,proto3" json:"hop_timeout_ms,omitempty"`
+}
+
+func (x *GossipRequest) Reset() {
+	*x = GossipRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_liars_network_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GossipRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GossipRequest) ProtoMessage() {}
+
+func (x *GossipRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_liars_network_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GossipRequest.ProtoReflect.Descriptor instead.
+func (*GossipRequest) Descriptor() ([]byte, []int) {
+	return file_liars_network_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GossipRequest) GetHops() int32 {
+	if x != nil {
+		return x.Hops
+	}
+	return 0
+}
+
+func (x *GossipRequest) GetRoute() []string {
+	if x != nil {
+		return x.Route
+	}
+	return nil
+}
+
+func (x *GossipRequest) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+func (x *GossipRequest) GetHopTimeoutMs() int64 {
+	if x != nil {
+		return x.HopTimeoutMs
+	}
+	return 0
+}
+
+type GossipResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Records []*GossipRecord `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+}
+
+func (x *GossipResponse) Reset() {
+	*x = GossipResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_liars_network_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GossipResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GossipResponse) ProtoMessage() {}
+
+func (x *GossipResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_liars_network_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GossipResponse.ProtoReflect.Descriptor instead.
+func (*GossipResponse) Descriptor() ([]byte, []int) {
+	return file_liars_network_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GossipResponse) GetRecords() []*GossipRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+type SetNeighborsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// host:port addresses of the agents the agent gossips with.
+	Neighbors []string `protobuf:"bytes,1,rep,name=neighbors,proto3" json:"neighbors,omitempty"`
+}
+
+func (x *SetNeighborsRequest) Reset() {
+	*x = SetNeighborsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_liars_network_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SetNeighborsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetNeighborsRequest) ProtoMessage() {}
+
+func (x *SetNeighborsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_liars_network_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetNeighborsRequest.ProtoReflect.Descriptor instead.
+func (*SetNeighborsRequest) Descriptor() ([]byte, []int) {
+	return file_liars_network_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SetNeighborsRequest) GetNeighbors() []string {
+	if x != nil {
+		return x.Neighbors
+	}
+	return nil
+}
+
+type SetNeighborsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *SetNeighborsResponse) Reset() {
+	*x = SetNeighborsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_liars_network_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SetNeighborsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetNeighborsResponse) ProtoMessage() {}
+
+func (x *SetNeighborsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_liars_network_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetNeighborsResponse.ProtoReflect.Descriptor instead.
+func (*SetNeighborsResponse) Descriptor() ([]byte, []int) {
+	return file_liars_network_proto_rawDescGZIP(), []int{7}
+}
+
+// Asks an agent to run the PBFT protocol among replicas and report the value they agree on.
+type AgreeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// host:port addresses of every agent taking part in the protocol, including the one asked.
+	Replicas []string `protobuf:"bytes,1,rep,name=replicas,proto3" json:"replicas,omitempty"`
 }
 
-func (x *LieResponse) Reset() {
-	*x = LieResponse{}
+func (x *AgreeRequest) Reset() {
+	*x = AgreeRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_liars_network_proto_msgTypes[1]
+		mi := &file_liars_network_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *LieResponse) String() string {
+func (x *AgreeRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LieResponse) ProtoMessage() {}
+func (*AgreeRequest) ProtoMessage() {}
 
-func (x *LieResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_liars_network_proto_msgTypes[1]
+func (x *AgreeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_liars_network_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -112,50 +585,326 @@ func (x *LieResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LieResponse.ProtoReflect.Descriptor instead.
-func (*LieResponse) Descriptor() ([]byte, []int) {
-	return file_liars_network_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use AgreeRequest.ProtoReflect.Descriptor instead.
+func (*AgreeRequest) Descriptor() ([]byte, []int) {
+	return file_liars_network_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *LieResponse) GetAgentValue() int32 {
+func (x *AgreeRequest) GetReplicas() []string {
 	if x != nil {
-		return x.AgentValue
+		return x.Replicas
+	}
+	return nil
+}
+
+type AgreeResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// if false, then the replicas failed to agree on any value.
+	Committed bool  `protobuf:"varint,1,opt,name=committed,proto3" json:"committed,omitempty"`
+	Value     int32 `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+	// the view in which the value was committed.
+	View int32 `protobuf:"varint,3,opt,name=view,proto3" json:"view,omitempty"`
+}
+
+func (x *AgreeResponse) Reset() {
+	*x = AgreeResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_liars_network_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AgreeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgreeResponse) ProtoMessage() {}
+
+func (x *AgreeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_liars_network_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgreeResponse.ProtoReflect.Descriptor instead.
+func (*AgreeResponse) Descriptor() ([]byte, []int) {
+	return file_liars_network_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AgreeResponse) GetCommitted() bool {
+	if x != nil {
+		return x.Committed
+	}
+	return false
+}
+
+func (x *AgreeResponse) GetValue() int32 {
+	if x != nil {
+		return x.Value
 	}
 	return 0
 }
 
-func (x *LieResponse) GetCollectedAgentValues() []int32 {
+func (x *AgreeResponse) GetView() int32 {
 	if x != nil {
-		return x.CollectedAgentValues
+		return x.View
+	}
+	return 0
+}
+
+// A message exchanged between agents running the PBFT protocol.
+type PbftMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// identifies one run of the protocol.
+	Sequence int64 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	View     int32 `protobuf:"varint,2,opt,name=view,proto3" json:"view,omitempty"`
+	Value    int32 `protobuf:"varint,3,opt,name=value,proto3" json:"value,omitempty"`
+	// host:port address of the agent sending the message.
+	Sender   string   `protobuf:"bytes,4,opt,name=sender,proto3" json:"sender,omitempty"`
+	Replicas []string `protobuf:"bytes,5,rep,name=replicas,proto3" json:"replicas,omitempty"`
+}
+
+func (x *PbftMessage) Reset() {
+	*x = PbftMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_liars_network_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PbftMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PbftMessage) ProtoMessage() {}
+
+func (x *PbftMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_liars_network_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PbftMessage.ProtoReflect.Descriptor instead.
+func (*PbftMessage) Descriptor() ([]byte, []int) {
+	return file_liars_network_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PbftMessage) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *PbftMessage) GetView() int32 {
+	if x != nil {
+		return x.View
+	}
+	return 0
+}
+
+func (x *PbftMessage) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *PbftMessage) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *PbftMessage) GetReplicas() []string {
+	if x != nil {
+		return x.Replicas
 	}
 	return nil
 }
 
+type PbftAck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *PbftAck) Reset() {
+	*x = PbftAck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_liars_network_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PbftAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PbftAck) ProtoMessage() {}
+
+func (x *PbftAck) ProtoReflect() protoreflect.Message {
+	mi := &file_liars_network_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PbftAck.ProtoReflect.Descriptor instead.
+func (*PbftAck) Descriptor() ([]byte, []int) {
+	return file_liars_network_proto_rawDescGZIP(), []int{11}
+}
+
 var File_liars_network_proto protoreflect.FileDescriptor
 
 var file_liars_network_proto_rawDesc = []byte{
 	0x0a, 0x13, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2e,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0d, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74,
-	0x77, 0x6f, 0x72, 0x6b, 0x22, 0x55, 0x0a, 0x0a, 0x4c, 0x69, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x65, 0x78, 0x70, 0x65, 0x72, 0x74, 0x5f, 0x6d, 0x6f, 0x64,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x65, 0x78, 0x70, 0x65, 0x72, 0x74, 0x4d,
-	0x6f, 0x64, 0x65, 0x12, 0x26, 0x0a, 0x0f, 0x6f, 0x74, 0x68, 0x65, 0x72, 0x5f, 0x61, 0x67, 0x65,
-	0x6e, 0x74, 0x5f, 0x69, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x6f, 0x74,
-	0x68, 0x65, 0x72, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x73, 0x22, 0x64, 0x0a, 0x0b, 0x4c,
-	0x69, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x67,
-	0x65, 0x6e, 0x74, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x0a, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x34, 0x0a, 0x16, 0x63,
-	0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x5f, 0x76,
-	0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x05, 0x52, 0x14, 0x63, 0x6f, 0x6c,
-	0x6c, 0x65, 0x63, 0x74, 0x65, 0x64, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x56, 0x61, 0x6c, 0x75, 0x65,
-	0x73, 0x32, 0x51, 0x0a, 0x0a, 0x4c, 0x69, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12,
-	0x43, 0x0a, 0x08, 0x4c, 0x69, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x19, 0x2e, 0x6c, 0x69,
-	0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2e, 0x4c, 0x69, 0x65, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e,
-	0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2e, 0x4c, 0x69, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x22, 0x00, 0x42, 0x1f, 0x5a, 0x1d, 0x2e, 0x2f, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f,
-	0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x3b, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65,
-	0x74, 0x77, 0x6f, 0x72, 0x6b, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x77, 0x6f, 0x72, 0x6b, 0x22, 0xa9, 0x01, 0x0a, 0x0a, 0x4c, 0x69, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x65, 0x78, 0x70, 0x65, 0x72, 0x74, 0x5f, 0x6d, 0x6f,
+	0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x65, 0x78, 0x70, 0x65, 0x72, 0x74,
+	0x4d, 0x6f, 0x64, 0x65, 0x12, 0x26, 0x0a, 0x0f, 0x6f, 0x74, 0x68, 0x65, 0x72, 0x5f, 0x61, 0x67,
+	0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x6f,
+	0x74, 0x68, 0x65, 0x72, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x73, 0x12, 0x12, 0x0a, 0x04,
+	0x68, 0x6f, 0x70, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x68, 0x6f, 0x70, 0x73,
+	0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x28, 0x0a, 0x10, 0x71, 0x75, 0x65, 0x72, 0x79, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0e, 0x71, 0x75, 0x65, 0x72, 0x79, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4d, 0x73,
+	0x22, 0xbb, 0x02, 0x0a, 0x0b, 0x4c, 0x69, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x56, 0x61, 0x6c, 0x75,
+	0x65, 0x12, 0x34, 0x0a, 0x16, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x65, 0x64, 0x5f, 0x61,
+	0x67, 0x65, 0x6e, 0x74, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x05, 0x52, 0x14, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x65, 0x64, 0x41, 0x67, 0x65, 0x6e,
+	0x74, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x12, 0x32, 0x0a, 0x15, 0x75, 0x6e, 0x72, 0x65, 0x61,
+	0x63, 0x68, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x13, 0x75, 0x6e, 0x72, 0x65, 0x61, 0x63, 0x68, 0x61,
+	0x62, 0x6c, 0x65, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x73, 0x12, 0x42, 0x0a, 0x0e, 0x67,
+	0x6f, 0x73, 0x73, 0x69, 0x70, 0x5f, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x04, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77,
+	0x6f, 0x72, 0x6b, 0x2e, 0x47, 0x6f, 0x73, 0x73, 0x69, 0x70, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64,
+	0x52, 0x0d, 0x67, 0x6f, 0x73, 0x73, 0x69, 0x70, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x12,
+	0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x3f, 0x0a,
+	0x0d, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x06,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74,
+	0x77, 0x6f, 0x72, 0x6b, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x56, 0x61, 0x6c, 0x75, 0x65,
+	0x52, 0x0c, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x22, 0x5c,
+	0x0a, 0x0b, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x19, 0x0a,
+	0x08, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1c,
+	0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x22, 0x71, 0x0a, 0x0c,
+	0x47, 0x6f, 0x73, 0x73, 0x69, 0x70, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x19, 0x0a, 0x08,
+	0x61, 0x67, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x61, 0x67, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74,
+	0x68, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x22,
+	0x75, 0x0a, 0x0d, 0x47, 0x6f, 0x73, 0x73, 0x69, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x68, 0x6f, 0x70, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04,
+	0x68, 0x6f, 0x70, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x05, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f,
+	0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65,
+	0x12, 0x24, 0x0a, 0x0e, 0x68, 0x6f, 0x70, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f,
+	0x6d, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x68, 0x6f, 0x70, 0x54, 0x69, 0x6d,
+	0x65, 0x6f, 0x75, 0x74, 0x4d, 0x73, 0x22, 0x47, 0x0a, 0x0e, 0x47, 0x6f, 0x73, 0x73, 0x69, 0x70,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x35, 0x0a, 0x07, 0x72, 0x65, 0x63, 0x6f,
+	0x72, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x6c, 0x69, 0x61, 0x72,
+	0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2e, 0x47, 0x6f, 0x73, 0x73, 0x69, 0x70,
+	0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x07, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x22,
+	0x33, 0x0a, 0x13, 0x53, 0x65, 0x74, 0x4e, 0x65, 0x69, 0x67, 0x68, 0x62, 0x6f, 0x72, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x6e, 0x65, 0x69, 0x67, 0x68, 0x62,
+	0x6f, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x65, 0x69, 0x67, 0x68,
+	0x62, 0x6f, 0x72, 0x73, 0x22, 0x16, 0x0a, 0x14, 0x53, 0x65, 0x74, 0x4e, 0x65, 0x69, 0x67, 0x68,
+	0x62, 0x6f, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x2a, 0x0a, 0x0c,
+	0x41, 0x67, 0x72, 0x65, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08,
+	0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08,
+	0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x22, 0x57, 0x0a, 0x0d, 0x41, 0x67, 0x72, 0x65,
+	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6d,
+	0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x63, 0x6f,
+	0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x76, 0x69, 0x65, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x76, 0x69, 0x65,
+	0x77, 0x22, 0x87, 0x01, 0x0a, 0x0b, 0x50, 0x62, 0x66, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x76, 0x69, 0x65, 0x77, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x76, 0x69, 0x65,
+	0x77, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x6e, 0x64, 0x65,
+	0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x12,
+	0x1a, 0x0a, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x22, 0x09, 0x0a, 0x07, 0x50,
+	0x62, 0x66, 0x74, 0x41, 0x63, 0x6b, 0x32, 0xc1, 0x04, 0x0a, 0x0a, 0x4c, 0x69, 0x65, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x43, 0x0a, 0x08, 0x4c, 0x69, 0x65, 0x51, 0x75, 0x65, 0x72,
+	0x79, 0x12, 0x19, 0x2e, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72,
+	0x6b, 0x2e, 0x4c, 0x69, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x6c,
+	0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2e, 0x4c, 0x69, 0x65,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x44, 0x0a, 0x05, 0x41, 0x67,
+	0x72, 0x65, 0x65, 0x12, 0x1b, 0x2e, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77,
+	0x6f, 0x72, 0x6b, 0x2e, 0x41, 0x67, 0x72, 0x65, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x1c, 0x2e, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b,
+	0x2e, 0x41, 0x67, 0x72, 0x65, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00,
+	0x12, 0x3f, 0x0a, 0x07, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x2e, 0x6c, 0x69,
+	0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2e, 0x50, 0x62, 0x66, 0x74,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16, 0x2e, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f,
+	0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2e, 0x50, 0x62, 0x66, 0x74, 0x41, 0x63, 0x6b, 0x22,
+	0x00, 0x12, 0x42, 0x0a, 0x0a, 0x50, 0x72, 0x65, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x12,
+	0x1a, 0x2e, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2e,
+	0x50, 0x62, 0x66, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16, 0x2e, 0x6c, 0x69,
+	0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2e, 0x50, 0x62, 0x66, 0x74,
+	0x41, 0x63, 0x6b, 0x22, 0x00, 0x12, 0x3f, 0x0a, 0x07, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65,
+	0x12, 0x1a, 0x2e, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b,
+	0x2e, 0x50, 0x62, 0x66, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16, 0x2e, 0x6c,
+	0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2e, 0x50, 0x62, 0x66,
+	0x74, 0x41, 0x63, 0x6b, 0x22, 0x00, 0x12, 0x3e, 0x0a, 0x06, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74,
+	0x12, 0x1a, 0x2e, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b,
+	0x2e, 0x50, 0x62, 0x66, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x16, 0x2e, 0x6c,
+	0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2e, 0x50, 0x62, 0x66,
+	0x74, 0x41, 0x63, 0x6b, 0x22, 0x00, 0x12, 0x47, 0x0a, 0x06, 0x47, 0x6f, 0x73, 0x73, 0x69, 0x70,
+	0x12, 0x1c, 0x2e, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b,
+	0x2e, 0x47, 0x6f, 0x73, 0x73, 0x69, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d,
+	0x2e, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2e, 0x47,
+	0x6f, 0x73, 0x73, 0x69, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12,
+	0x59, 0x0a, 0x0c, 0x53, 0x65, 0x74, 0x4e, 0x65, 0x69, 0x67, 0x68, 0x62, 0x6f, 0x72, 0x73, 0x12,
+	0x22, 0x2e, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2e,
+	0x53, 0x65, 0x74, 0x4e, 0x65, 0x69, 0x67, 0x68, 0x62, 0x6f, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x23, 0x2e, 0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77,
+	0x6f, 0x72, 0x6b, 0x2e, 0x53, 0x65, 0x74, 0x4e, 0x65, 0x69, 0x67, 0x68, 0x62, 0x6f, 0x72, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x1f, 0x5a, 0x1d, 0x2e, 0x2f,
+	0x6c, 0x69, 0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x3b, 0x6c, 0x69,
+	0x61, 0x72, 0x73, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -170,19 +919,46 @@ func file_liars_network_proto_rawDescGZIP() []byte {
 	return file_liars_network_proto_rawDescData
 }
 
-var file_liars_network_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_liars_network_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_liars_network_proto_goTypes = []interface{}{
-	(*LieRequest)(nil),  // 0: liars_network.LieRequest
-	(*LieResponse)(nil), // 1: liars_network.LieResponse
+	(*LieRequest)(nil),           // 0: liars_network.LieRequest
+	(*LieResponse)(nil),          // 1: liars_network.LieResponse
+	(*SignedValue)(nil),          // 2: liars_network.SignedValue
+	(*GossipRecord)(nil),         // 3: liars_network.GossipRecord
+	(*GossipRequest)(nil),        // 4: liars_network.GossipRequest
+	(*GossipResponse)(nil),       // 5: liars_network.GossipResponse
+	(*SetNeighborsRequest)(nil),  // 6: liars_network.SetNeighborsRequest
+	(*SetNeighborsResponse)(nil), // 7: liars_network.SetNeighborsResponse
+	(*AgreeRequest)(nil),         // 8: liars_network.AgreeRequest
+	(*AgreeResponse)(nil),        // 9: liars_network.AgreeResponse
+	(*PbftMessage)(nil),          // 10: liars_network.PbftMessage
+	(*PbftAck)(nil),              // 11: liars_network.PbftAck
 }
 var file_liars_network_proto_depIdxs = []int32{
-	0, // 0: liars_network.LieService.LieQuery:input_type -> liars_network.LieRequest
-	1, // 1: liars_network.LieService.LieQuery:output_type -> liars_network.LieResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	3,  // 0: liars_network.LieResponse.gossip_records:type_name -> liars_network.GossipRecord
+	2,  // 1: liars_network.LieResponse.signed_values:type_name -> liars_network.SignedValue
+	3,  // 2: liars_network.GossipResponse.records:type_name -> liars_network.GossipRecord
+	0,  // 3: liars_network.LieService.LieQuery:input_type -> liars_network.LieRequest
+	8,  // 4: liars_network.LieService.Agree:input_type -> liars_network.AgreeRequest
+	10, // 5: liars_network.LieService.Request:input_type -> liars_network.PbftMessage
+	10, // 6: liars_network.LieService.PrePrepare:input_type -> liars_network.PbftMessage
+	10, // 7: liars_network.LieService.Prepare:input_type -> liars_network.PbftMessage
+	10, // 8: liars_network.LieService.Commit:input_type -> liars_network.PbftMessage
+	4,  // 9: liars_network.LieService.Gossip:input_type -> liars_network.GossipRequest
+	6,  // 10: liars_network.LieService.SetNeighbors:input_type -> liars_network.SetNeighborsRequest
+	1,  // 11: liars_network.LieService.LieQuery:output_type -> liars_network.LieResponse
+	9,  // 12: liars_network.LieService.Agree:output_type -> liars_network.AgreeResponse
+	11, // 13: liars_network.LieService.Request:output_type -> liars_network.PbftAck
+	11, // 14: liars_network.LieService.PrePrepare:output_type -> liars_network.PbftAck
+	11, // 15: liars_network.LieService.Prepare:output_type -> liars_network.PbftAck
+	11, // 16: liars_network.LieService.Commit:output_type -> liars_network.PbftAck
+	5,  // 17: liars_network.LieService.Gossip:output_type -> liars_network.GossipResponse
+	7,  // 18: liars_network.LieService.SetNeighbors:output_type -> liars_network.SetNeighborsResponse
+	11, // [11:19] is the sub-list for method output_type
+	3,  // [3:11] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_liars_network_proto_init() }
@@ -215,6 +991,126 @@ func file_liars_network_proto_init() {
 				return nil
 			}
 		}
+		file_liars_network_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SignedValue); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_liars_network_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GossipRecord); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_liars_network_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GossipRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_liars_network_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GossipResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_liars_network_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SetNeighborsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_liars_network_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SetNeighborsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_liars_network_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AgreeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_liars_network_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AgreeResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_liars_network_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PbftMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_liars_network_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PbftAck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -222,7 +1118,7 @@ func file_liars_network_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_liars_network_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
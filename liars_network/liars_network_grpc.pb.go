@@ -23,6 +23,14 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type LieServiceClient interface {
 	LieQuery(ctx context.Context, in *LieRequest, opts ...grpc.CallOption) (*LieResponse, error)
+	Agree(ctx context.Context, in *AgreeRequest, opts ...grpc.CallOption) (*AgreeResponse, error)
+	// Asks the primary of a view to propose its value.
+	Request(ctx context.Context, in *PbftMessage, opts ...grpc.CallOption) (*PbftAck, error)
+	PrePrepare(ctx context.Context, in *PbftMessage, opts ...grpc.CallOption) (*PbftAck, error)
+	Prepare(ctx context.Context, in *PbftMessage, opts ...grpc.CallOption) (*PbftAck, error)
+	Commit(ctx context.Context, in *PbftMessage, opts ...grpc.CallOption) (*PbftAck, error)
+	Gossip(ctx context.Context, in *GossipRequest, opts ...grpc.CallOption) (*GossipResponse, error)
+	SetNeighbors(ctx context.Context, in *SetNeighborsRequest, opts ...grpc.CallOption) (*SetNeighborsResponse, error)
 }
 
 type lieServiceClient struct {
@@ -42,11 +50,82 @@ func (c *lieServiceClient) LieQuery(ctx context.Context, in *LieRequest, opts ..
 	return out, nil
 }
 
+func (c *lieServiceClient) Agree(ctx context.Context, in *AgreeRequest, opts ...grpc.CallOption) (*AgreeResponse, error) {
+	out := new(AgreeResponse)
+	err := c.cc.Invoke(ctx, "/liars_network.LieService/Agree", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lieServiceClient) Request(ctx context.Context, in *PbftMessage, opts ...grpc.CallOption) (*PbftAck, error) {
+	out := new(PbftAck)
+	err := c.cc.Invoke(ctx, "/liars_network.LieService/Request", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lieServiceClient) PrePrepare(ctx context.Context, in *PbftMessage, opts ...grpc.CallOption) (*PbftAck, error) {
+	out := new(PbftAck)
+	err := c.cc.Invoke(ctx, "/liars_network.LieService/PrePrepare", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lieServiceClient) Prepare(ctx context.Context, in *PbftMessage, opts ...grpc.CallOption) (*PbftAck, error) {
+	out := new(PbftAck)
+	err := c.cc.Invoke(ctx, "/liars_network.LieService/Prepare", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lieServiceClient) Commit(ctx context.Context, in *PbftMessage, opts ...grpc.CallOption) (*PbftAck, error) {
+	out := new(PbftAck)
+	err := c.cc.Invoke(ctx, "/liars_network.LieService/Commit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lieServiceClient) Gossip(ctx context.Context, in *GossipRequest, opts ...grpc.CallOption) (*GossipResponse, error) {
+	out := new(GossipResponse)
+	err := c.cc.Invoke(ctx, "/liars_network.LieService/Gossip", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lieServiceClient) SetNeighbors(ctx context.Context, in *SetNeighborsRequest, opts ...grpc.CallOption) (*SetNeighborsResponse, error) {
+	out := new(SetNeighborsResponse)
+	err := c.cc.Invoke(ctx, "/liars_network.LieService/SetNeighbors", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LieServiceServer is the server API for LieService service.
 // All implementations must embed UnimplementedLieServiceServer
 // for forward compatibility
 type LieServiceServer interface {
 	LieQuery(context.Context, *LieRequest) (*LieResponse, error)
+	Agree(context.Context, *AgreeRequest) (*AgreeResponse, error)
+	// Asks the primary of a view to propose its value.
+	Request(context.Context, *PbftMessage) (*PbftAck, error)
+	PrePrepare(context.Context, *PbftMessage) (*PbftAck, error)
+	Prepare(context.Context, *PbftMessage) (*PbftAck, error)
+	Commit(context.Context, *PbftMessage) (*PbftAck, error)
+	Gossip(context.Context, *GossipRequest) (*GossipResponse, error)
+	SetNeighbors(context.Context, *SetNeighborsRequest) (*SetNeighborsResponse, error)
 	mustEmbedUnimplementedLieServiceServer()
 }
 
@@ -57,6 +136,27 @@ type UnimplementedLieServiceServer struct {
 func (UnimplementedLieServiceServer) LieQuery(context.Context, *LieRequest) (*LieResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LieQuery not implemented")
 }
+func (UnimplementedLieServiceServer) Agree(context.Context, *AgreeRequest) (*AgreeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Agree not implemented")
+}
+func (UnimplementedLieServiceServer) Request(context.Context, *PbftMessage) (*PbftAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Request not implemented")
+}
+func (UnimplementedLieServiceServer) PrePrepare(context.Context, *PbftMessage) (*PbftAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PrePrepare not implemented")
+}
+func (UnimplementedLieServiceServer) Prepare(context.Context, *PbftMessage) (*PbftAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Prepare not implemented")
+}
+func (UnimplementedLieServiceServer) Commit(context.Context, *PbftMessage) (*PbftAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Commit not implemented")
+}
+func (UnimplementedLieServiceServer) Gossip(context.Context, *GossipRequest) (*GossipResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Gossip not implemented")
+}
+func (UnimplementedLieServiceServer) SetNeighbors(context.Context, *SetNeighborsRequest) (*SetNeighborsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetNeighbors not implemented")
+}
 func (UnimplementedLieServiceServer) mustEmbedUnimplementedLieServiceServer() {}
 
 // UnsafeLieServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -88,6 +188,132 @@ func _LieService_LieQuery_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LieService_Agree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AgreeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LieServiceServer).Agree(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/liars_network.LieService/Agree",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LieServiceServer).Agree(ctx, req.(*AgreeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LieService_Request_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PbftMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LieServiceServer).Request(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/liars_network.LieService/Request",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LieServiceServer).Request(ctx, req.(*PbftMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LieService_PrePrepare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PbftMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LieServiceServer).PrePrepare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/liars_network.LieService/PrePrepare",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LieServiceServer).PrePrepare(ctx, req.(*PbftMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LieService_Prepare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PbftMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LieServiceServer).Prepare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/liars_network.LieService/Prepare",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LieServiceServer).Prepare(ctx, req.(*PbftMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LieService_Commit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PbftMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LieServiceServer).Commit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/liars_network.LieService/Commit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LieServiceServer).Commit(ctx, req.(*PbftMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LieService_Gossip_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GossipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LieServiceServer).Gossip(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/liars_network.LieService/Gossip",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LieServiceServer).Gossip(ctx, req.(*GossipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LieService_SetNeighbors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetNeighborsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LieServiceServer).SetNeighbors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/liars_network.LieService/SetNeighbors",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LieServiceServer).SetNeighbors(ctx, req.(*SetNeighborsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // LieService_ServiceDesc is the grpc.ServiceDesc for LieService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -99,6 +325,34 @@ var LieService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "LieQuery",
 			Handler:    _LieService_LieQuery_Handler,
 		},
+		{
+			MethodName: "Agree",
+			Handler:    _LieService_Agree_Handler,
+		},
+		{
+			MethodName: "Request",
+			Handler:    _LieService_Request_Handler,
+		},
+		{
+			MethodName: "PrePrepare",
+			Handler:    _LieService_PrePrepare_Handler,
+		},
+		{
+			MethodName: "Prepare",
+			Handler:    _LieService_Prepare_Handler,
+		},
+		{
+			MethodName: "Commit",
+			Handler:    _LieService_Commit_Handler,
+		},
+		{
+			MethodName: "Gossip",
+			Handler:    _LieService_Gossip_Handler,
+		},
+		{
+			MethodName: "SetNeighbors",
+			Handler:    _LieService_SetNeighbors_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "liars_network.proto",
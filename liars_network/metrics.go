@@ -0,0 +1,220 @@
+package liars_network
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The metrics are written in the Prometheus text format, version 0.0.4, so that a Prometheus server can
+// scrape them from the /metrics endpoint served by MetricsRegistry.Serve.
+
+// The upper bounds of the buckets of every histogram, in seconds, the same as the default buckets of
+// the Prometheus client libraries.
+var DEFAULT_BUCKETS = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// A metric which is written along with the others of its registry.
+type metric interface {
+	write(writer io.Writer)
+}
+
+// Holds the metrics of a process, in the order they are written.
+type MetricsRegistry struct {
+	mutex   sync.Mutex
+	metrics []metric
+}
+
+// The registry of the metrics of this package, along with those of the client.
+var DefaultMetrics = new(MetricsRegistry)
+
+func (registry *MetricsRegistry) register(metric metric) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.metrics = append(registry.metrics, metric)
+}
+
+// Writes every metric in the Prometheus text format.
+func (registry *MetricsRegistry) WriteMetrics(writer io.Writer) {
+	registry.mutex.Lock()
+	metrics := append([]metric{}, registry.metrics...)
+	registry.mutex.Unlock()
+	for _, metric := range metrics {
+		metric.write(writer)
+	}
+}
+
+// Serves the metrics of registry over http://address/metrics until the process exits. Returns the
+// address it listens on, whose port is the next available one if the port of address is 0.
+func (registry *MetricsRegistry) Serve(address string) (string, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return "", err
+	}
+	serve_mux := http.NewServeMux()
+	serve_mux.HandleFunc("/metrics", func(response http.ResponseWriter, request *http.Request) {
+		response.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		registry.WriteMetrics(response)
+	})
+	go http.Serve(listener, serve_mux)
+	return listener.Addr().String(), nil
+}
+
+// The values of the series of a metric, by the values of their labels.
+type series[T any] struct {
+	mutex        sync.Mutex
+	label_names  []string
+	label_values map[string][]string
+	values       map[string]*T
+}
+
+// Returns the value of the series of label_values, creating it with create if there is none yet.
+// Must be called with the mutex held.
+func (series *series[T]) get(label_values []string, create func() *T) *T {
+	if len(label_values) != len(series.label_names) {
+		panic(fmt.Sprintf("expected the values of the labels %v, but got %v", series.label_names, label_values))
+	}
+	key := strings.Join(label_values, "\xff")
+	value, exists := series.values[key]
+	if !exists {
+		value = create()
+		series.values[key] = value
+		series.label_values[key] = append([]string{}, label_values...)
+	}
+	return value
+}
+
+// The keys of the series, sorted so that the metrics are written in a stable order.
+func (series *series[T]) keys() []string {
+	keys := make([]string, 0, len(series.values))
+	for key := range series.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Formats the labels of a series, along with extra_labels, as {name="value",...}.
+func (series *series[T]) formatLabels(key string, extra_labels ...string) string {
+	var labels []string
+	for i, label_value := range series.label_values[key] {
+		labels = append(labels, series.label_names[i]+"="+strconv.Quote(label_value))
+	}
+	labels = append(labels, extra_labels...)
+	if len(labels) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+// A counter, which only goes up, with one series for each combination of the values of its labels.
+type Counter struct {
+	name string
+	help string
+	series[float64]
+}
+
+// Creates a counter with the labels label_names and registers it in registry.
+func (registry *MetricsRegistry) NewCounter(name string, help string, label_names ...string) *Counter {
+	counter := &Counter{name: name, help: help,
+		series: series[float64]{label_names: label_names, label_values: map[string][]string{}, values: map[string]*float64{}}}
+	registry.register(counter)
+	return counter
+}
+
+// Adds delta, which must not be negative, to the series of label_values.
+func (counter *Counter) Add(delta float64, label_values ...string) {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	*counter.get(label_values, func() *float64 { return new(float64) }) += delta
+}
+
+func (counter *Counter) Inc(label_values ...string) {
+	counter.Add(1, label_values...)
+}
+
+func (counter *Counter) write(writer io.Writer) {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	fmt.Fprintf(writer, "# HELP %s %s\n# TYPE %s counter\n", counter.name, counter.help, counter.name)
+	for _, key := range counter.keys() {
+		fmt.Fprintf(writer, "%s%s %s\n", counter.name, counter.formatLabels(key), formatMetricValue(*counter.values[key]))
+	}
+}
+
+type histogramValue struct {
+	// The number of observations no greater than the upper bound of each bucket.
+	bucket_counts []uint64
+	sum           float64
+	count         uint64
+}
+
+// A histogram of observations, such as latencies, with one series for each combination of the values
+// of its labels.
+type Histogram struct {
+	name    string
+	help    string
+	buckets []float64
+	series[histogramValue]
+}
+
+// Creates a histogram with the labels label_names over the ascending upper bounds of buckets, and
+// registers it in registry.
+func (registry *MetricsRegistry) NewHistogram(name string, help string, buckets []float64, label_names ...string) *Histogram {
+	histogram := &Histogram{name: name, help: help, buckets: buckets,
+		series: series[histogramValue]{label_names: label_names, label_values: map[string][]string{}, values: map[string]*histogramValue{}}}
+	registry.register(histogram)
+	return histogram
+}
+
+func (histogram *Histogram) Observe(value float64, label_values ...string) {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+	histogram_value := histogram.get(label_values, func() *histogramValue {
+		return &histogramValue{bucket_counts: make([]uint64, len(histogram.buckets))}
+	})
+	for i, upper_bound := range histogram.buckets {
+		if value <= upper_bound {
+			histogram_value.bucket_counts[i]++
+		}
+	}
+	histogram_value.sum += value
+	histogram_value.count++
+}
+
+func (histogram *Histogram) write(writer io.Writer) {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+	fmt.Fprintf(writer, "# HELP %s %s\n# TYPE %s histogram\n", histogram.name, histogram.help, histogram.name)
+	for _, key := range histogram.keys() {
+		histogram_value := histogram.values[key]
+		for i, upper_bound := range histogram.buckets {
+			fmt.Fprintf(writer, "%s_bucket%s %d\n", histogram.name,
+				histogram.formatLabels(key, `le="`+formatMetricValue(upper_bound)+`"`), histogram_value.bucket_counts[i])
+		}
+		fmt.Fprintf(writer, "%s_bucket%s %d\n", histogram.name, histogram.formatLabels(key, `le="+Inf"`), histogram_value.count)
+		fmt.Fprintf(writer, "%s_sum%s %s\n", histogram.name, histogram.formatLabels(key), formatMetricValue(histogram_value.sum))
+		fmt.Fprintf(writer, "%s_count%s %d\n", histogram.name, histogram.formatLabels(key), histogram_value.count)
+	}
+}
+
+func formatMetricValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// The metrics of the agents. Every agent, whether it runs as a goroutine of the client or as a separate
+// process, is told apart by the address it is reached through.
+var (
+	agent_lie_queries_total = DefaultMetrics.NewCounter("liarslie_agent_lie_queries_total",
+		"The LieQuery calls the agent answered, by kind of query.", "agent", "kind")
+	agent_fan_outs_total = DefaultMetrics.NewCounter("liarslie_agent_fan_outs_total",
+		"The expert queries the agent fanned out to other agents as a proxy agent.", "agent")
+	agent_errors_total = DefaultMetrics.NewCounter("liarslie_agent_errors_total",
+		"The agents which the agent failed to reach while fanning out.", "agent")
+	agent_lie_query_duration_seconds = DefaultMetrics.NewHistogram("liarslie_agent_lie_query_duration_seconds",
+		"How long the agent took to answer a LieQuery call, by kind of query.", DEFAULT_BUCKETS, "agent", "kind")
+)
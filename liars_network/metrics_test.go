@@ -0,0 +1,48 @@
+package liars_network
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetricsRegistry(t *testing.T) {
+	registry := new(MetricsRegistry)
+	counter := registry.NewCounter("test_queries_total", "The queries.", "agent")
+	histogram := registry.NewHistogram("test_duration_seconds", "How long the queries took.", []float64{0.1, 1})
+	counter.Inc("localhost:9001")
+	counter.Add(2, "localhost:9000")
+	histogram.Observe(0.05)
+	histogram.Observe(0.5)
+	histogram.Observe(2)
+
+	address, err := registry.Serve("localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to serve the metrics: %s", err)
+	}
+	response, err := http.Get("http://" + address + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to scrape the metrics: %s", err)
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	expected_body := `# HELP test_queries_total The queries.
+# TYPE test_queries_total counter
+test_queries_total{agent="localhost:9000"} 2
+test_queries_total{agent="localhost:9001"} 1
+# HELP test_duration_seconds How long the queries took.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{le="0.1"} 1
+test_duration_seconds_bucket{le="1"} 2
+test_duration_seconds_bucket{le="+Inf"} 3
+test_duration_seconds_sum 2.55
+test_duration_seconds_count 3
+`
+	if string(body) != expected_body {
+		t.Errorf("Expected the metrics\n%s\nbut scraped\n%s", expected_body, body)
+	}
+	if content_type := response.Header.Get("Content-Type"); !strings.HasPrefix(content_type, "text/plain; version=0.0.4") {
+		t.Errorf("Expected the Prometheus text format, but got %s", content_type)
+	}
+}
@@ -0,0 +1,263 @@
+package liars_network
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The agents run a simplified PBFT protocol among themselves to agree on the network value. The agent
+// asked by the client coordinates the run: for each view in turn, it asks the primary of the view, which
+// is the view-th replica, to propose its value in a pre-prepare message. A replica accepts the proposal
+// only if it matches the value it holds itself, in which case it broadcasts a prepare message. Once a
+// replica collects a quorum of matching prepares, it broadcasts a commit message, and once any replica,
+// including one which did not accept the value itself, collects a quorum of matching commits, the value
+// is committed. The protocol tolerates f = (n-1)/3 liars among n replicas, as any two quorums then share
+// at least one honest replica. A view which does not commit in time, e.g. because its primary lies or
+// crashed, is abandoned for the next one. As honest replicas never accept any value but their own, views
+// do not need to carry over prepared values as in full PBFT. The coordinator reports the outcome of the
+// run faithfully whatever its strategy, so a liar only gets in the way of the agreement as a replica.
+
+// How long the coordinator waits for a view to commit before moving on to the next view.
+const PBFT_VIEW_TIMEOUT = 2 * time.Second
+
+// How long an agent waits for another agent to acknowledge a PBFT message.
+const PBFT_MESSAGE_TIMEOUT = time.Second
+
+// The longest a run of the protocol among replicas_num replicas takes.
+func AgreeTimeout(replicas_num int) time.Duration {
+	return time.Duration(replicas_num) * PBFT_VIEW_TIMEOUT
+}
+
+// The number of matching prepare/commit messages a replica needs to collect among replicas_num replicas.
+// This is 2f+1 if replicas_num is 3f+1.
+func PbftQuorum(replicas_num int) int {
+	faults_num := (replicas_num - 1) / 3
+	return (replicas_num+faults_num)/2 + 1
+}
+
+// The state of one run of the protocol, identified by its sequence, on one replica.
+type pbftInstance struct {
+	replicas []string
+	// When the run is over, after which the instance is pruned along with any late message of the run.
+	expiry          time.Time
+	views           map[int32]*pbftView
+	is_committed    bool
+	committed_value int32
+	committed_view  int32
+	// Closed once a value is committed.
+	committed chan struct{}
+}
+
+type pbftView struct {
+	is_pre_prepared bool
+	value           int32
+	// The senders of the prepare and commit messages, by the value they carry.
+	prepares map[int32]map[string]bool
+	commits  map[int32]map[string]bool
+	// Whether the replica collected a quorum of prepares and broadcast its commit.
+	is_prepared bool
+}
+
+// Asks the agent at address to run the protocol among replicas, waiting at most timeout for the result.
+func AskAgreement(address string, replicas []string, timeout time.Duration) (*AgreeResponse, error) {
+	conn, err := dialAgent(address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return NewLieServiceClient(conn).Agree(ctx, &AgreeRequest{Replicas: replicas})
+}
+
+// Coordinates a run of the protocol among the replicas of agree_request. The outcome is reported as is,
+// even if the agent lies when queried.
+func (agent *Agent) Agree(ctx context.Context, agree_request *AgreeRequest) (*AgreeResponse, error) {
+	replicas := agree_request.GetReplicas()
+	if !containsAddress(replicas, agent.RetrieveAddress()) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not one of the replicas", agent.RetrieveAddress())
+	}
+	sequence := agent.random.Int63()
+	agent.pbft_mutex.Lock()
+	instance := agent.findPbftInstance(sequence, replicas)
+	agent.pbft_mutex.Unlock()
+
+	for view := int32(0); int(view) < len(replicas); view++ {
+		request := &PbftMessage{Sequence: sequence, View: view, Sender: agent.RetrieveAddress(), Replicas: replicas}
+		// If the primary cannot even be reached, there is no point in waiting for the view to commit.
+		if err := sendPbftMessage(replicas[view], request, LieServiceClient.Request); err != nil {
+			continue
+		}
+		select {
+		case <-instance.committed:
+			return &AgreeResponse{Committed: true, Value: instance.committed_value, View: instance.committed_view}, nil
+		case <-time.After(PBFT_VIEW_TIMEOUT):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	select {
+	case <-instance.committed:
+		return &AgreeResponse{Committed: true, Value: instance.committed_value, View: instance.committed_view}, nil
+	default:
+		return &AgreeResponse{Committed: false}, nil
+	}
+}
+
+// Handled by the primary of the view, which proposes its own value to every replica.
+func (agent *Agent) Request(ctx context.Context, request *PbftMessage) (*PbftAck, error) {
+	replicas := request.GetReplicas()
+	if !isPbftPrimary(replicas, request.GetView(), agent.RetrieveAddress()) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not the primary of view %d", agent.RetrieveAddress(), request.GetView())
+	}
+	agent.broadcastPbftMessage(&PbftMessage{Sequence: request.GetSequence(), View: request.GetView(),
+		Value: agent.value(), Sender: agent.RetrieveAddress(), Replicas: replicas}, LieServiceClient.PrePrepare)
+	return &PbftAck{}, nil
+}
+
+func (agent *Agent) PrePrepare(ctx context.Context, pre_prepare *PbftMessage) (*PbftAck, error) {
+	if !isPbftPrimary(pre_prepare.GetReplicas(), pre_prepare.GetView(), pre_prepare.GetSender()) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not the primary of view %d", pre_prepare.GetSender(), pre_prepare.GetView())
+	}
+	// An honest agent only accepts the network value, while a liar only accepts its own lie.
+	if pre_prepare.GetValue() != agent.value() {
+		return &PbftAck{}, nil
+	}
+	agent.pbft_mutex.Lock()
+	view := agent.findPbftInstance(pre_prepare.GetSequence(), pre_prepare.GetReplicas()).findView(pre_prepare.GetView())
+	is_duplicate := view.is_pre_prepared
+	view.is_pre_prepared, view.value = true, pre_prepare.GetValue()
+	agent.pbft_mutex.Unlock()
+	if !is_duplicate {
+		prepare := &PbftMessage{Sequence: pre_prepare.GetSequence(), View: pre_prepare.GetView(), Value: pre_prepare.GetValue(),
+			Sender: agent.RetrieveAddress(), Replicas: pre_prepare.GetReplicas()}
+		agent.broadcastPbftMessage(prepare, LieServiceClient.Prepare)
+		// Prepares which arrived before the pre-prepare may already form a quorum.
+		agent.advancePbft(prepare)
+	}
+	return &PbftAck{}, nil
+}
+
+func (agent *Agent) Prepare(ctx context.Context, prepare *PbftMessage) (*PbftAck, error) {
+	if !containsAddress(prepare.GetReplicas(), prepare.GetSender()) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not one of the replicas", prepare.GetSender())
+	}
+	agent.pbft_mutex.Lock()
+	view := agent.findPbftInstance(prepare.GetSequence(), prepare.GetReplicas()).findView(prepare.GetView())
+	addPbftSender(view.prepares, prepare)
+	agent.pbft_mutex.Unlock()
+	agent.advancePbft(prepare)
+	return &PbftAck{}, nil
+}
+
+func (agent *Agent) Commit(ctx context.Context, commit *PbftMessage) (*PbftAck, error) {
+	if !containsAddress(commit.GetReplicas(), commit.GetSender()) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not one of the replicas", commit.GetSender())
+	}
+	agent.pbft_mutex.Lock()
+	view := agent.findPbftInstance(commit.GetSequence(), commit.GetReplicas()).findView(commit.GetView())
+	addPbftSender(view.commits, commit)
+	agent.pbft_mutex.Unlock()
+	agent.advancePbft(commit)
+	return &PbftAck{}, nil
+}
+
+// Moves the view of message forward once enough matching prepares or commits are collected.
+func (agent *Agent) advancePbft(message *PbftMessage) {
+	agent.pbft_mutex.Lock()
+	instance := agent.findPbftInstance(message.GetSequence(), message.GetReplicas())
+	view := instance.findView(message.GetView())
+	quorum := PbftQuorum(len(instance.replicas))
+	should_commit := false
+	if view.is_pre_prepared && !view.is_prepared && len(view.prepares[view.value]) >= quorum {
+		view.is_prepared = true
+		should_commit = true
+	}
+	if !instance.is_committed && len(view.commits[message.GetValue()]) >= quorum {
+		instance.is_committed = true
+		instance.committed_value, instance.committed_view = message.GetValue(), message.GetView()
+		close(instance.committed)
+	}
+	value := view.value
+	agent.pbft_mutex.Unlock()
+	if should_commit {
+		agent.broadcastPbftMessage(&PbftMessage{Sequence: message.GetSequence(), View: message.GetView(), Value: value,
+			Sender: agent.RetrieveAddress(), Replicas: message.GetReplicas()}, LieServiceClient.Commit)
+	}
+}
+
+// Sends message to every replica, including the agent itself, without waiting for the replicas.
+func (agent *Agent) broadcastPbftMessage(message *PbftMessage,
+	rpc func(LieServiceClient, context.Context, *PbftMessage, ...grpc.CallOption) (*PbftAck, error)) {
+	for _, replica := range message.GetReplicas() {
+		go sendPbftMessage(replica, message, rpc)
+	}
+}
+
+func sendPbftMessage(address string, message *PbftMessage,
+	rpc func(LieServiceClient, context.Context, *PbftMessage, ...grpc.CallOption) (*PbftAck, error)) error {
+	conn, err := dialAgent(address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), PBFT_MESSAGE_TIMEOUT)
+	defer cancel()
+	_, err = rpc(NewLieServiceClient(conn), ctx, message)
+	return err
+}
+
+// Returns the instance of sequence, creating it if this is the first message of it, in which case the
+// instances of the runs which are over are pruned. Must be called with pbft_mutex held.
+func (agent *Agent) findPbftInstance(sequence int64, replicas []string) *pbftInstance {
+	if agent.pbft_instances == nil {
+		agent.pbft_instances = map[int64]*pbftInstance{}
+	}
+	instance, exists := agent.pbft_instances[sequence]
+	if !exists {
+		now := time.Now()
+		for other_sequence, other_instance := range agent.pbft_instances {
+			if now.After(other_instance.expiry) {
+				delete(agent.pbft_instances, other_sequence)
+			}
+		}
+		// Messages of the last view may still be on their way once the coordinator gave up on it.
+		instance = &pbftInstance{replicas: replicas, expiry: now.Add(AgreeTimeout(len(replicas)) + PBFT_VIEW_TIMEOUT),
+			views: map[int32]*pbftView{}, committed: make(chan struct{})}
+		agent.pbft_instances[sequence] = instance
+	}
+	return instance
+}
+
+func (instance *pbftInstance) findView(view_number int32) *pbftView {
+	view, exists := instance.views[view_number]
+	if !exists {
+		view = &pbftView{prepares: map[int32]map[string]bool{}, commits: map[int32]map[string]bool{}}
+		instance.views[view_number] = view
+	}
+	return view
+}
+
+func addPbftSender(senders map[int32]map[string]bool, message *PbftMessage) {
+	if senders[message.GetValue()] == nil {
+		senders[message.GetValue()] = map[string]bool{}
+	}
+	senders[message.GetValue()][message.GetSender()] = true
+}
+
+func isPbftPrimary(replicas []string, view int32, address string) bool {
+	return view >= 0 && int(view) < len(replicas) && replicas[view] == address
+}
+
+func containsAddress(addresses []string, address string) bool {
+	for _, other_address := range addresses {
+		if other_address == address {
+			return true
+		}
+	}
+	return false
+}
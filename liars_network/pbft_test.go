@@ -0,0 +1,48 @@
+package liars_network
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPbftQuorum(t *testing.T) {
+	for replicas_num, expected_quorum := range map[int]int{1: 1, 2: 2, 3: 2, 4: 3, 7: 5, 10: 7} {
+		if quorum := PbftQuorum(replicas_num); quorum != expected_quorum {
+			t.Errorf("The quorum of %d replicas should be %d, got %d", replicas_num, expected_quorum, quorum)
+		}
+	}
+}
+
+func TestAgree(t *testing.T) {
+	// The primary of the first view lies, so the honest agents only agree in the second view.
+	replicas := []string{launchStrategyAgent(t, &ConstantLie{value: 9}), launchTestAgent(t, 3), launchTestAgent(t, 3),
+		launchTestAgent(t, 3)}
+	response, err := AskAgreement(replicas[1], replicas, AgreeTimeout(len(replicas)))
+	if err != nil {
+		t.Fatalf("Failed to ask for agreement: %s", err)
+	}
+	if !response.Committed || response.Value != 3 || response.View != 1 {
+		t.Errorf("The replicas should agree on 3 in view 1, got %+v", response)
+	}
+
+	// The liar does not accept the value itself, but still learns that the others committed it.
+	response, err = AskAgreement(replicas[0], replicas, AgreeTimeout(len(replicas)))
+	if err != nil || !response.Committed || response.Value != 3 {
+		t.Errorf("The replicas should agree on 3 when asked through the liar, got %+v, %v", response, err)
+	}
+
+	if _, err := AskAgreement(replicas[1], replicas[2:], time.Second); err == nil {
+		t.Errorf("An agent which is not one of the replicas should refuse to coordinate")
+	}
+}
+
+func TestPrunePbftInstances(t *testing.T) {
+	agent := new(Agent)
+	replicas := []string{"localhost:4000", "localhost:4001"}
+	agent.findPbftInstance(1, replicas).expiry = time.Now().Add(-time.Second)
+	agent.findPbftInstance(2, replicas)
+	agent.findPbftInstance(3, replicas)
+	if _, exists := agent.pbft_instances[1]; exists || len(agent.pbft_instances) != 2 {
+		t.Errorf("Only the instance of the run which is over should be pruned, got %v", agent.pbft_instances)
+	}
+}
@@ -0,0 +1,261 @@
+package liars_network
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// How long a graceful Stop waits for an agent process to exit before killing it.
+const AGENT_PROCESS_STOP_TIMEOUT = 5 * time.Second
+
+// An agent running as a child process of the client, launched through the agent subcommand of
+// the executable.
+type AgentProcess struct {
+	executable  string
+	host        string
+	port_number int
+	command     *exec.Cmd
+	public_key  ed25519.PublicKey
+	// The certificate the agent process authenticates with, or nil if it does not use TLS.
+	tls_files *TLSFiles
+	// What the agent process seeds its generator with.
+	seed int64
+	// The address the agent process is asked to serve its metrics over, or "" if it does not.
+	metrics_address string
+	// The address the agent process serves its metrics over, once it reported it.
+	served_metrics_address string
+	// Closed once the child process is reaped.
+	exited chan struct{}
+}
+
+// Launches an agent process over host:listen_port by running `executable agent ...`. If listen_port
+// is 0, the agent listens on the next available port instead. The agent process reports the port
+// it listens on, followed by its hex encoded public key and the address it serves its metrics over, if
+// it does, as the first line of its standard output. If
+// tls_files is not nil, the agent process requires mTLS with the certificate in tls_files. The agent
+// process draws its random lies and tampering from a generator seeded with seed. If metrics_address is
+// not empty, the agent process serves its metrics over http://metrics_address/metrics.
+func LaunchAgentProcess(executable string, host string, listen_port int, strategy_type StrategyType, agent_value int32,
+	network_value int32, max_value int32, tamper_probability float64, tls_files *TLSFiles, seed int64,
+	metrics_address string) (*AgentProcess, error) {
+	args := []string{"agent",
+		"--value", strconv.FormatInt(int64(agent_value), 10),
+		"--host", host,
+		"--port", strconv.Itoa(listen_port),
+		"--network-value", strconv.FormatInt(int64(network_value), 10),
+		"--max-value", strconv.FormatInt(int64(max_value), 10),
+		"--strategy", strategy_type.String(),
+		"--tamper", strconv.FormatFloat(tamper_probability, 'g', -1, 64),
+		"--seed", strconv.FormatInt(seed, 10)}
+	if tls_files != nil {
+		args = append(args, "--tls-cert", tls_files.CertificatePath, "--tls-key", tls_files.KeyPath, "--tls-ca", tls_files.AuthorityPath)
+	}
+	if metrics_address != "" {
+		args = append(args, "--metrics-addr", metrics_address)
+	}
+	command := exec.Command(executable, args...)
+	command.Stderr = os.Stderr
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := command.Start(); err != nil {
+		return nil, err
+	}
+	agent_process := &AgentProcess{executable: executable, host: host, command: command, tls_files: tls_files, seed: seed,
+		metrics_address: metrics_address, exited: make(chan struct{})}
+	// Whatever the agent process prints after its first line is forwarded, the same as an agent running as
+	// a goroutine. The child process is reaped as soon as it exits, so that it never lingers as a zombie,
+	// but only once all of its output is forwarded, so that Stop and Kill return after its last message
+	// rather than leave it to show up during a later command.
+	first_lines := make(chan string, 1)
+	go func() {
+		stdout_reader := bufio.NewReader(stdout)
+		first_line, _ := stdout_reader.ReadString('\n')
+		first_lines <- first_line
+		io.Copy(output, stdout_reader)
+		command.Wait()
+		close(agent_process.exited)
+	}()
+
+	first_line := <-first_lines
+	if !strings.HasSuffix(first_line, "\n") {
+		agent_process.Kill()
+		return nil, fmt.Errorf("agent process %d exited before reporting its port", command.Process.Pid)
+	}
+	fields := strings.Fields(first_line)
+	if len(fields) != 2 && len(fields) != 3 {
+		agent_process.Kill()
+		return nil, fmt.Errorf("agent process %d reported %q rather than its port and public key", command.Process.Pid, first_line)
+	}
+	agent_process.port_number, err = strconv.Atoi(fields[0])
+	if err != nil {
+		agent_process.Kill()
+		return nil, fmt.Errorf("agent process %d reported an invalid port: %w", command.Process.Pid, err)
+	}
+	if agent_process.public_key, err = DecodePublicKey(fields[1]); err != nil {
+		agent_process.Kill()
+		return nil, fmt.Errorf("agent process %d reported an invalid public key: %w", command.Process.Pid, err)
+	}
+	if len(fields) == 3 {
+		agent_process.served_metrics_address = fields[2]
+		fmt.Fprintln(output, "Serving the metrics of the agent on port", agent_process.port_number, "over http://"+fields[2]+"/metrics")
+	}
+	return agent_process, nil
+}
+
+// Terminates the agent process and waits for it to exit. If it does not exit in time, it is killed.
+func (agent_process *AgentProcess) Stop() {
+	fmt.Fprintln(output, "Stopping agent process", agent_process.command.Process.Pid, "on port number: ", agent_process.port_number)
+	agent_process.command.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-agent_process.exited:
+	case <-time.After(AGENT_PROCESS_STOP_TIMEOUT):
+		agent_process.Kill()
+	}
+}
+
+// Kills the agent process with SIGKILL, which is a real crash from the point of view of the network.
+func (agent_process *AgentProcess) Kill() {
+	agent_process.command.Process.Kill()
+	<-agent_process.exited
+}
+
+// The strategy of a running process cannot be swapped from the client, so the agent process is
+// stopped and relaunched with the new strategy over the same port, and serves its metrics over the same
+// address as well. Fails if it cannot be relaunched, in which case the agent process stays stopped.
+func (agent_process *AgentProcess) Reassign(strategy_type StrategyType, agent_value int32, network_value int32, max_value int32,
+	tamper_probability float64) error {
+	agent_process.Stop()
+	metrics_address := agent_process.metrics_address
+	if agent_process.served_metrics_address != "" {
+		metrics_address = agent_process.served_metrics_address
+	}
+	relaunched, err := LaunchAgentProcess(agent_process.executable, agent_process.host, agent_process.port_number, strategy_type,
+		agent_value, network_value, max_value, tamper_probability, agent_process.tls_files, agent_process.seed, metrics_address)
+	if err != nil {
+		return fmt.Errorf("failed to relaunch the agent process on port number %d: %w", agent_process.port_number, err)
+	}
+	*agent_process = *relaunched
+	return nil
+}
+
+func (agent_process *AgentProcess) IsMatchingAddress(address string) bool {
+	return agent_process.RetrieveAddress() == address
+}
+
+func (agent_process *AgentProcess) RetrievePortNum() string {
+	return strconv.FormatInt(int64(agent_process.port_number), 10)
+}
+
+func (agent_process *AgentProcess) RetrieveAddress() string {
+	return net.JoinHostPort(ReachableHost(agent_process.host), agent_process.RetrievePortNum())
+}
+
+func (agent_process *AgentProcess) RetrievePid() int {
+	return agent_process.command.Process.Pid
+}
+
+func (agent_process *AgentProcess) RetrievePublicKey() ed25519.PublicKey {
+	return agent_process.public_key
+}
+
+func (agent_process *AgentProcess) RetrieveMetricsAddress() string {
+	return agent_process.served_metrics_address
+}
+
+// Runs a single agent in this process until it is terminated, which is what the agent subcommand of the
+// client does:
+// liarslie agent --value v --port p [--host h --network-value n --max-value max --strategy name --tamper p
+// --seed s --tls-cert file --tls-key file --tls-ca file --metrics-addr address]
+// The port number the agent listens on, its public key and, with --metrics-addr, the address it serves its
+// metrics over are printed as the first line of the standard output.
+func RunAgentProcess(args []string) {
+	agent_flags := flag.NewFlagSet("agent", flag.ExitOnError)
+	value := agent_flags.Int64("value", 0, "The value the agent is assigned.")
+	host := agent_flags.String("host", "", "The host the agent binds to. Binds to all the interfaces if empty.")
+	port := agent_flags.Int("port", 0, "The port the agent listens on. 0 picks the next available port.")
+	network_value := agent_flags.Int64("network-value", 0, "The network value. Defaults to --value, which makes the agent honest.")
+	max_value := agent_flags.Int64("max-value", 0, "The max value a liar can answer with. Defaults to --value.")
+	strategy_name := agent_flags.String("strategy", "constant", "The strategy the agent follows if it is a liar.")
+	tamper_probability := agent_flags.Float64("tamper", 0, "The probability with which the agent tampers with every value it relays.")
+	seed := agent_flags.Int64("seed", 0, "Seeds the random lies and tampering of the agent. Defaults to the current time.")
+	tls_files := TLSFiles{}
+	agent_flags.StringVar(&tls_files.CertificatePath, "tls-cert", "", "The certificate the agent authenticates with over mTLS.")
+	agent_flags.StringVar(&tls_files.KeyPath, "tls-key", "", "The private key of --tls-cert.")
+	agent_flags.StringVar(&tls_files.AuthorityPath, "tls-ca", "", "The certificate of the CA which issued the certificates of the network.")
+	metrics_address := agent_flags.String("metrics-addr", "", "Serves the metrics of the agent over http://address/metrics. "+
+		"Port 0 picks the next available port.")
+	agent_flags.Parse(args)
+	// The agent is honest unless told otherwise.
+	is_flag_set := map[string]bool{}
+	agent_flags.Visit(func(set_flag *flag.Flag) { is_flag_set[set_flag.Name] = true })
+	if !is_flag_set["network-value"] {
+		*network_value = *value
+	}
+	if !is_flag_set["max-value"] {
+		*max_value = *value
+	}
+	strategy_type, valid := ParseStrategyType(*strategy_name)
+	if !valid {
+		log.Fatalln("strategy must be one of constant, random, collude or flip")
+	}
+
+	var wait_group sync.WaitGroup
+	wait_group.Add(1)
+	port_number := make(chan int)
+	agent := new(Agent)
+	if !is_flag_set["seed"] {
+		*seed = time.Now().UnixNano()
+	}
+	random := NewRandom(*seed)
+	strategy := NewStrategy(strategy_type, int32(*value), int32(*network_value), int32(*max_value), random)
+	agent.SetRandom(random)
+	agent.SetTamperProbability(*tamper_probability)
+	// With TLS, the agent both serves and dials the other agents with its certificate.
+	if is_flag_set["tls-cert"] || is_flag_set["tls-key"] || is_flag_set["tls-ca"] {
+		server_credentials, err := NewServerCredentials(&tls_files)
+		if err != nil {
+			log.Fatalln("Failed to load the certificate of the agent: ", err)
+		}
+		agent.SetServerCredentials(server_credentials)
+		dial_config, err := NewDialConfig(&tls_files)
+		if err != nil {
+			log.Fatalln("Failed to load the certificate of the agent: ", err)
+		}
+		SetDialConfig(dial_config)
+	}
+	// Serves until the client (or anyone else) asks the agent to terminate, which it may do as soon as the
+	// agent reports its port.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	go agent.Init(port_number, net.JoinHostPort(*host, strconv.Itoa(*port)), strategy, &wait_group)
+	listen_port := <-port_number
+	wait_group.Wait()
+	// The client prints where the metrics are served itself, as it reads the first line before it moves on.
+	if *metrics_address != "" {
+		served_address, err := DefaultMetrics.Serve(*metrics_address)
+		if err != nil {
+			log.Fatalln("Failed to serve the metrics of the agent: ", err)
+		}
+		fmt.Println(listen_port, EncodePublicKey(agent.RetrievePublicKey()), served_address)
+	} else {
+		fmt.Println(listen_port, EncodePublicKey(agent.RetrievePublicKey()))
+	}
+
+	<-signals
+	agent.Stop()
+}
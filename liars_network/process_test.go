@@ -0,0 +1,205 @@
+package liars_network
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Makes the test binary run as an agent process rather than run the tests, so that agent processes
+// can be launched without building the client.
+const TEST_AGENT_PROCESS_ENV = "LIARSLIE_TEST_AGENT_PROCESS"
+
+// A test agent process run with TEST_AGENT_PROCESS_ENV set to this exits before reporting its port, as
+// if it failed to launch.
+const FAILING_TEST_AGENT_PROCESS = "fail"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(TEST_AGENT_PROCESS_ENV) {
+	case "":
+		os.Exit(m.Run())
+	case FAILING_TEST_AGENT_PROCESS:
+		os.Exit(1)
+	default:
+		RunAgentProcess(os.Args[2:])
+	}
+}
+
+// Launches a test agent process answering with agent_value on a free port of localhost.
+func launchTestAgentProcess(t *testing.T, agent_value int32) *AgentProcess {
+	t.Setenv(TEST_AGENT_PROCESS_ENV, "1")
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Failed to locate the test binary: %s", err)
+	}
+	agent_process, err := LaunchAgentProcess(executable, "127.0.0.1", 0, CONSTANT_LIE, agent_value, 3, 10, 0, nil, 1, "")
+	if err != nil {
+		t.Fatalf("Failed to launch the agent process: %s", err)
+	}
+	return agent_process
+}
+
+func TestAgentProcess(t *testing.T) {
+	agent_process := launchTestAgentProcess(t, 7)
+	if result := QueryAgent(agent_process.RetrieveAddress(), new(LieRequest), time.Second); result.Err != nil ||
+		result.Response.AgentValue != 7 {
+		t.Fatalf("The agent process should answer with 7, got %+v", result)
+	}
+	// Reassigning relaunches the agent process over the same port.
+	pid, address := agent_process.RetrievePid(), agent_process.RetrieveAddress()
+	if err := agent_process.Reassign(CONSTANT_LIE, 3, 3, 10, 0); err != nil {
+		t.Fatalf("Failed to reassign the agent process: %s", err)
+	}
+	if agent_process.RetrievePid() == pid || agent_process.RetrieveAddress() != address {
+		t.Errorf("The agent process should be relaunched over %s, got pid %d over %s", address, agent_process.RetrievePid(),
+			agent_process.RetrieveAddress())
+	}
+	if result := QueryAgent(address, new(LieRequest), time.Second); result.Err != nil || result.Response.AgentValue != 3 {
+		t.Errorf("The relaunched agent process should answer with 3, got %+v", result)
+	}
+
+	agent_process.Stop()
+	select {
+	case <-agent_process.exited:
+	default:
+		t.Errorf("The agent process should have exited once stopped")
+	}
+	if result := QueryAgent(address, new(LieRequest), time.Second); result.Err == nil {
+		t.Errorf("The stopped agent process should not answer, got %+v", result)
+	}
+}
+
+func TestReassignFailingAgentProcess(t *testing.T) {
+	agent_process := launchTestAgentProcess(t, 7)
+	t.Setenv(TEST_AGENT_PROCESS_ENV, FAILING_TEST_AGENT_PROCESS)
+	if err := agent_process.Reassign(CONSTANT_LIE, 3, 3, 10, 0); err == nil {
+		agent_process.Stop()
+		t.Fatalf("Reassigning should fail when the agent process cannot be relaunched")
+	}
+	if result := QueryAgent(agent_process.RetrieveAddress(), new(LieRequest), time.Second); result.Err == nil {
+		t.Errorf("The agent process which failed to relaunch should not answer, got %+v", result)
+	}
+}
+
+// Collects what is written to it from any goroutine.
+type lockedBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (locked_buffer *lockedBuffer) Write(message []byte) (int, error) {
+	locked_buffer.mutex.Lock()
+	defer locked_buffer.mutex.Unlock()
+	return locked_buffer.buffer.Write(message)
+}
+
+func (locked_buffer *lockedBuffer) String() string {
+	locked_buffer.mutex.Lock()
+	defer locked_buffer.mutex.Unlock()
+	return locked_buffer.buffer.String()
+}
+
+func TestAgentProcessOutput(t *testing.T) {
+	agent_output := new(lockedBuffer)
+	SetOutput(agent_output)
+	defer SetOutput(os.Stdout)
+	agent_process := launchTestAgentProcess(t, 7)
+	// The agent process prints that it stops as it exits, which is forwarded by the time Stop returns.
+	agent_process.Stop()
+	if expected := fmt.Sprint("Stopping grpc server on port number:  ", agent_process.port_number); !strings.Contains(agent_output.String(),
+		expected) {
+		t.Errorf("The output of the agent process should be forwarded once it stopped, got %q", agent_output.String())
+	}
+}
+
+func TestAgentProcessMetricsAddress(t *testing.T) {
+	t.Setenv(TEST_AGENT_PROCESS_ENV, "1")
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Failed to locate the test binary: %s", err)
+	}
+	agent_process, err := LaunchAgentProcess(executable, "127.0.0.1", 0, CONSTANT_LIE, 7, 3, 10, 0, nil, 1, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to launch the agent process: %s", err)
+	}
+	defer agent_process.Stop()
+	metrics_address := agent_process.RetrieveMetricsAddress()
+	if _, port, _ := net.SplitHostPort(metrics_address); port == "" || port == "0" {
+		t.Fatalf("The agent process should report the address it serves its metrics over, got %q", metrics_address)
+	}
+	// The metrics of the agent process are still reached through the same address once it is relaunched.
+	if err := agent_process.Reassign(CONSTANT_LIE, 3, 3, 10, 0); err != nil {
+		t.Fatalf("Failed to reassign the agent process: %s", err)
+	}
+	if agent_process.RetrieveMetricsAddress() != metrics_address {
+		t.Errorf("The relaunched agent process should serve its metrics over %s, got %s", metrics_address,
+			agent_process.RetrieveMetricsAddress())
+	}
+}
+
+func TestAgentProcessTLS(t *testing.T) {
+	authority, err := LoadOrCreateCertificateAuthority(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create the CA: %s", err)
+	}
+	port, err := AllocateFreePort("127.0.0.1")
+	if err != nil {
+		t.Fatalf("Failed to allocate a port: %s", err)
+	}
+	agent_files, err := authority.IssueAgentCertificate("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("Failed to issue the certificate of the agent: %s", err)
+	}
+	t.Setenv(TEST_AGENT_PROCESS_ENV, "1")
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Failed to locate the test binary: %s", err)
+	}
+	agent_process, err := LaunchAgentProcess(executable, "127.0.0.1", port, CONSTANT_LIE, 7, 3, 10, 0, agent_files, 1, "")
+	if err != nil {
+		t.Fatalf("Failed to launch the agent process: %s", err)
+	}
+	defer agent_process.Stop()
+
+	if result := QueryAgent(agent_process.RetrieveAddress(), new(LieRequest), time.Second); result.Err == nil {
+		t.Errorf("The agent process should refuse clients without TLS")
+	}
+	client_files, err := authority.IssueClientCertificate()
+	if err != nil {
+		t.Fatalf("Failed to issue the certificate of the client: %s", err)
+	}
+	dialWith(t, client_files)
+	if result := QueryAgent(agent_process.RetrieveAddress(), new(LieRequest), time.Second); result.Err != nil ||
+		result.Response.AgentValue != 7 {
+		t.Errorf("The agent process should answer 7 over mTLS, got %+v", result)
+	}
+}
+
+func TestTamperingAgentProcess(t *testing.T) {
+	t.Setenv(TEST_AGENT_PROCESS_ENV, "1")
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Failed to locate the test binary: %s", err)
+	}
+	proxy_process, err := LaunchAgentProcess(executable, "127.0.0.1", 0, CONSTANT_LIE, 9, 3, 10, 1, nil, 1, "")
+	if err != nil {
+		t.Fatalf("Failed to launch the agent process: %s", err)
+	}
+	defer proxy_process.Stop()
+
+	other_agent_ids := []string{launchTestAgent(t, 3), launchTestAgent(t, 3)}
+	result := QueryAgent(proxy_process.RetrieveAddress(), &LieRequest{ExpertMode: true, OtherAgentIds: other_agent_ids}, time.Second)
+	if result.Err != nil {
+		t.Fatalf("Failed to query the proxy agent process: %s", result.Err)
+	}
+	for _, value := range result.Response.CollectedAgentValues {
+		if value != 9 {
+			t.Errorf("An agent process launched with --tamper 1 should relay its own lie, got %v", result.Response.CollectedAgentValues)
+		}
+	}
+}
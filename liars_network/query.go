@@ -0,0 +1,70 @@
+package liars_network
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The result of querying a single agent. Exactly one of Response and Err is set.
+type QueryResult struct {
+	Address  string
+	Response *LieResponse
+	Err      error
+}
+
+// Whether the agent did not answer within the deadline of the query.
+func (result QueryResult) IsTimedOut() bool {
+	return status.Code(result.Err) == codes.DeadlineExceeded
+}
+
+// Sends lie_request to every agent in addresses concurrently, with at most workers_num queries in
+// flight at a time and every query bounded by timeout. The agents are queried one at a time if
+// workers_num is less than 1. Returns one result per agent, in the same order as addresses.
+func QueryAgents(addresses []string, lie_request *LieRequest, workers_num int, timeout time.Duration) []QueryResult {
+	if workers_num < 1 {
+		workers_num = 1
+	}
+	results := make([]QueryResult, len(addresses))
+	indices := make(chan int)
+	var wait_group sync.WaitGroup
+	for worker := 0; worker < workers_num && worker < len(addresses); worker++ {
+		wait_group.Add(1)
+		go func() {
+			defer wait_group.Done()
+			for i := range indices {
+				results[i] = QueryAgent(addresses[i], lie_request, timeout)
+			}
+		}()
+	}
+	for i := range addresses {
+		indices <- i
+	}
+	close(indices)
+	wait_group.Wait()
+	return results
+}
+
+// Sends lie_request to the agent at address, waiting at most timeout for its response.
+func QueryAgent(address string, lie_request *LieRequest, timeout time.Duration) QueryResult {
+	result := QueryResult{Address: address}
+	conn, err := dialAgent(address)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	result.Response, result.Err = NewLieServiceClient(conn).LieQuery(ctx, lie_request)
+	return result
+}
+
+// Opens a connection to the agent at address, with the TLS config set by SetDialConfig.
+func dialAgent(address string) (*grpc.ClientConn, error) {
+	return grpc.Dial(address, grpc.WithTransportCredentials(dialCredentials(address)))
+}
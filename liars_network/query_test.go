@@ -0,0 +1,119 @@
+package liars_network
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Launches an agent answering with value on a free port of localhost and returns its address.
+func launchTestAgent(t *testing.T, value int32) string {
+	return launchStrategyAgent(t, &HonestStrategy{value: value})
+}
+
+// Launches an agent following strategy on a free port of localhost and returns its address.
+func launchStrategyAgent(t *testing.T, strategy Strategy) string {
+	var wait_group sync.WaitGroup
+	wait_group.Add(1)
+	port_number := make(chan int)
+	agent := new(Agent)
+	go agent.Init(port_number, "127.0.0.1:0", strategy, &wait_group)
+	<-port_number
+	wait_group.Wait()
+	t.Cleanup(agent.Stop)
+	return agent.RetrieveAddress()
+}
+
+// Listens on a free port of localhost, accepting connections but never answering them, and returns
+// its address.
+func listenSilently(t *testing.T) string {
+	silent_listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	t.Cleanup(func() { silent_listener.Close() })
+	go func() {
+		// Holds on to the connections until the listener is closed, as they would otherwise be closed once
+		// they are garbage collected.
+		var silent_conns []net.Conn
+		defer func() {
+			for _, conn := range silent_conns {
+				conn.Close()
+			}
+		}()
+		for {
+			conn, err := silent_listener.Accept()
+			if err != nil {
+				return
+			}
+			silent_conns = append(silent_conns, conn)
+		}
+	}()
+	return silent_listener.Addr().String()
+}
+
+func TestQueryAgents(t *testing.T) {
+	addresses := []string{launchTestAgent(t, 3), listenSilently(t), launchTestAgent(t, 5)}
+	results := QueryAgents(addresses, new(LieRequest), 2, 500*time.Millisecond)
+	if len(results) != 3 {
+		t.Fatalf("There should be one result per agent, got %v", results)
+	}
+	if results[0].Err != nil || results[0].Response.AgentValue != 3 || results[0].Address != addresses[0] {
+		t.Errorf("The first agent should answer with 3, got %+v", results[0])
+	}
+	if !results[1].IsTimedOut() {
+		t.Errorf("The silent agent should time out, got %+v", results[1])
+	}
+	if results[2].Err != nil || results[2].Response.AgentValue != 5 {
+		t.Errorf("The third agent should answer with 5, got %+v", results[2])
+	}
+}
+
+func TestQueryAgentsWithoutWorkers(t *testing.T) {
+	addresses := []string{launchTestAgent(t, 3), launchTestAgent(t, 5)}
+	for _, workers_num := range []int{0, -1} {
+		results := QueryAgents(addresses, new(LieRequest), workers_num, time.Second)
+		if len(results) != 2 || results[0].Err != nil || results[0].Response.AgentValue != 3 || results[1].Err != nil ||
+			results[1].Response.AgentValue != 5 {
+			t.Errorf("The agents should still be queried with %d workers, got %+v", workers_num, results)
+		}
+	}
+}
+
+func TestTamperingProxy(t *testing.T) {
+	var wait_group sync.WaitGroup
+	wait_group.Add(1)
+	port_number := make(chan int)
+	proxy_agent := new(Agent)
+	proxy_agent.SetTamperProbability(1)
+	go proxy_agent.Init(port_number, "127.0.0.1:0", &ConstantLie{value: 9}, &wait_group)
+	<-port_number
+	wait_group.Wait()
+	defer proxy_agent.Stop()
+
+	other_agent_ids := []string{launchTestAgent(t, 3), launchTestAgent(t, 3)}
+	result := QueryAgent(proxy_agent.RetrieveAddress(), &LieRequest{ExpertMode: true, OtherAgentIds: other_agent_ids}, time.Second)
+	if result.Err != nil {
+		t.Fatalf("Failed to query the proxy agent: %s", result.Err)
+	}
+	for _, value := range result.Response.CollectedAgentValues {
+		if value != 9 {
+			t.Errorf("A proxy agent which always tampers should relay its own lie, got %v", result.Response.CollectedAgentValues)
+		}
+	}
+}
+
+func TestProxyQueryTimeout(t *testing.T) {
+	// The proxy agent waits for the silent agent as long as the request says, rather than PROXY_QUERY_TIMEOUT.
+	proxy_address, silent_address := launchTestAgent(t, 3), listenSilently(t)
+	start_time := time.Now()
+	result := QueryAgent(proxy_address, &LieRequest{ExpertMode: true, OtherAgentIds: []string{silent_address}, QueryTimeoutMs: 200},
+		PROXY_QUERY_TIMEOUT)
+	if result.Err != nil || len(result.Response.UnreachableAgentIds) != 1 {
+		t.Fatalf("The silent agent should be unreachable, got %+v", result)
+	}
+	if elapsed := time.Since(start_time); elapsed >= PROXY_QUERY_TIMEOUT/2 {
+		t.Errorf("The proxy agent should give up on the silent agent after 200ms, but took %s", elapsed)
+	}
+}
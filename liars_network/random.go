@@ -0,0 +1,89 @@
+package liars_network
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A rand.Source which is safe for concurrent use, so that an agent can draw from the same generator
+// while it serves several queries at the same time.
+type lockedSource struct {
+	mutex  sync.Mutex
+	source rand.Source
+}
+
+func (locked_source *lockedSource) Int63() int64 {
+	locked_source.mutex.Lock()
+	defer locked_source.mutex.Unlock()
+	return locked_source.source.Int63()
+}
+
+func (locked_source *lockedSource) Seed(seed int64) {
+	locked_source.mutex.Lock()
+	defer locked_source.mutex.Unlock()
+	locked_source.source.Seed(seed)
+}
+
+// Returns a generator seeded with seed which is safe for concurrent use. Every agent draws from its own
+// generator, so that what an agent draws does not depend on what the other agents in the same process
+// drew before it.
+func NewRandom(seed int64) *rand.Rand {
+	return rand.New(&lockedSource{source: rand.NewSource(seed)})
+}
+
+// A range of ports which agents are launched over in order, so that the same agents are reached
+// through the same ports in every run.
+type PortRange struct {
+	first int
+	last  int
+	next  int
+}
+
+// Parses either first-last, or first alone for every port from first up.
+func ParsePortRange(port_range string) (*PortRange, error) {
+	first, last, has_last := strings.Cut(port_range, "-")
+	if !has_last {
+		last = "65535"
+	}
+	first_port, err := strconv.Atoi(first)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a port", first)
+	}
+	last_port, err := strconv.Atoi(last)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a port", last)
+	}
+	if first_port < 1 || last_port > 65535 || first_port > last_port {
+		return nil, fmt.Errorf("%s is not a range of ports within [1, 65535]", port_range)
+	}
+	return &PortRange{first: first_port, last: last_port, next: first_port}, nil
+}
+
+// Returns the next port of the range which is free on host, skipping the ones which are in use.
+func (port_range *PortRange) Allocate(host string) (int, error) {
+	for ; port_range.next <= port_range.last; port_range.next++ {
+		listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port_range.next)))
+		if err != nil {
+			continue
+		}
+		listener.Close()
+		port_range.next++
+		return port_range.next - 1, nil
+	}
+	return 0, fmt.Errorf("no port is left in the range of %d-%d", port_range.first, port_range.last)
+}
+
+// Returns a port which is free on host at the moment, for an agent which needs to know its port before
+// it is launched.
+func AllocateFreePort(host string) (int, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,56 @@
+package liars_network
+
+import (
+	"net"
+	"strconv"
+	"testing"
+)
+
+func TestNewRandom(t *testing.T) {
+	random_lie_1 := NewStrategy(RANDOM_LIE, 7, 3, 1000, NewRandom(42))
+	random_lie_2 := NewStrategy(RANDOM_LIE, 7, 3, 1000, NewRandom(42))
+	for i := 0; i < 10; i++ {
+		if answer_1, answer_2 := random_lie_1.Answer(), random_lie_2.Answer(); answer_1 != answer_2 {
+			t.Fatalf("Random liars seeded alike should answer alike, got %v and %v", answer_1, answer_2)
+		}
+	}
+}
+
+func TestPortRange(t *testing.T) {
+	for _, invalid_range := range []string{"", "port", "9000-", "0-10", "10-9", "9000-70000"} {
+		if _, err := ParsePortRange(invalid_range); err == nil {
+			t.Errorf("%q should not be parsed as a range of ports", invalid_range)
+		}
+	}
+	// Occupies the first port of a range, which is then skipped. The port after it may be in use by the
+	// agents of other tests, in which case another first port is picked.
+	first_port := 0
+	for attempt := 0; attempt < 10 && first_port == 0; attempt++ {
+		listener, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %s", err)
+		}
+		defer listener.Close()
+		port := listener.Addr().(*net.TCPAddr).Port
+		if port == 65535 {
+			continue
+		}
+		if next_listener, err := net.Listen("tcp", net.JoinHostPort("localhost", strconv.Itoa(port+1))); err == nil {
+			next_listener.Close()
+			first_port = port
+		}
+	}
+	if first_port == 0 {
+		t.Skip("No port picked by the system is followed by a free one.")
+	}
+	port_range, err := ParsePortRange(strconv.Itoa(first_port) + "-" + strconv.Itoa(first_port+1))
+	if err != nil {
+		t.Fatalf("Failed to parse the range of ports: %s", err)
+	}
+	if port, err := port_range.Allocate("localhost"); err != nil || port != first_port+1 {
+		t.Errorf("Expected port %d to be allocated, got %d (%v)", first_port+1, port, err)
+	}
+	if _, err := port_range.Allocate("localhost"); err == nil {
+		t.Errorf("No port should be left in the range.")
+	}
+}
@@ -0,0 +1,177 @@
+package liars_network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long LoadRegistry waits for an agent recorded in the config file to accept a connection.
+const AGENT_HEALTH_CHECK_TIMEOUT = time.Second
+
+// A Registry owns the agents launched by the client together with the agents.config file which
+// records them. Every change to the membership of the network goes through the registry, which
+// rewrites the config file right away, so the config on disk never diverges from the running agents.
+type Registry struct {
+	mutex       sync.Mutex
+	config_path string
+	config      *AgentsConfig
+	agents      []LaunchedAgent
+}
+
+// Creates an empty registry backed by the config file at config_path. The file is not written
+// until the first agent is added.
+func NewRegistry(config_path string) *Registry {
+	return &Registry{config_path: config_path, config: NewAgentsConfig()}
+}
+
+// Creates a registry backed by the config file at config_path, which keeps the records of the agents
+// in the existing config file, if there is one. The agents recorded there were launched by another
+// client, so they can be queried through their records but cannot be stopped from this registry. The
+// records of the agents which no longer accept connections are dropped, and the config file is
+// rewritten without them.
+func LoadRegistry(config_path string) (*Registry, error) {
+	registry := NewRegistry(config_path)
+	config, err := ReadAgentsConfig(config_path)
+	if errors.Is(err, os.ErrNotExist) {
+		return registry, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	registry.config = config
+	var dead_addresses []string
+	for i, is_alive := range checkAgentsAlive(config.Agents) {
+		if !is_alive {
+			dead_addresses = append(dead_addresses, config.Agents[i].Address())
+		}
+	}
+	if len(dead_addresses) == 0 {
+		return registry, nil
+	}
+	for _, address := range dead_addresses {
+		config.Remove(address)
+	}
+	fmt.Fprintln(output, "Dropped", len(dead_addresses), "agents recorded in", config_path, "which are no longer running:",
+		strings.Join(dead_addresses, ", "))
+	return registry, config.Write(config_path)
+}
+
+// Returns whether each of the agents recorded in records accepts connections, which are checked concurrently.
+func checkAgentsAlive(records []*AgentRecord) []bool {
+	is_alive := make([]bool, len(records))
+	var wait_group sync.WaitGroup
+	for i, record := range records {
+		wait_group.Add(1)
+		go func(i int, address string) {
+			defer wait_group.Done()
+			conn, err := net.DialTimeout("tcp", address, AGENT_HEALTH_CHECK_TIMEOUT)
+			if err == nil {
+				conn.Close()
+				is_alive[i] = true
+			}
+		}(i, record.Address())
+	}
+	wait_group.Wait()
+	return is_alive
+}
+
+// Adds a launched agent along with its record, which is assigned the next agent id.
+func (registry *Registry) Add(agent LaunchedAgent, record *AgentRecord) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.agents = append(registry.agents, agent)
+	registry.config.Add(record)
+	return registry.config.Write(registry.config_path)
+}
+
+// Returns the address of the agent identified by agent_id, which is either its host:port address or,
+// as long as no other agent listens on the same port, its bare port number.
+func (registry *Registry) ResolveAddress(agent_id string) (string, error) {
+	port_number, err := strconv.Atoi(agent_id)
+	if err != nil {
+		return agent_id, nil
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	var addresses []string
+	for _, record := range registry.config.Agents {
+		if record.Port == port_number {
+			addresses = append(addresses, record.Address())
+		}
+	}
+	switch len(addresses) {
+	case 0:
+		return "", fmt.Errorf("no agent listens on port %d", port_number)
+	case 1:
+		return addresses[0], nil
+	}
+	return "", fmt.Errorf("%d agents listen on port %d, so the agent must be given as one of %s", len(addresses), port_number,
+		strings.Join(addresses, ", "))
+}
+
+// Removes the record of the agent reached through address, along with the agent if it was launched
+// through this registry. The launched agent is returned so that the caller can stop it, or nil if the
+// agent was launched by another client. Returns false if there is no agent reached through address.
+func (registry *Registry) Remove(address string) (LaunchedAgent, bool, error) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if !registry.config.Remove(address) {
+		return nil, false, nil
+	}
+	var removed_agent LaunchedAgent
+	for i, agent := range registry.agents {
+		if agent.IsMatchingAddress(address) {
+			registry.agents = append(registry.agents[:i], registry.agents[i+1:]...)
+			removed_agent = agent
+			break
+		}
+	}
+	return removed_agent, true, registry.config.Write(registry.config_path)
+}
+
+// Applies update to the record of the launched agent and rewrites the config file.
+func (registry *Registry) Update(agent LaunchedAgent, update func(record *AgentRecord)) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if record := registry.config.Find(agent.RetrieveAddress()); record != nil {
+		update(record)
+	}
+	return registry.config.Write(registry.config_path)
+}
+
+// Forgets every agent and deletes the config file. The agents are not stopped.
+func (registry *Registry) Clear() error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.agents = nil
+	registry.config = NewAgentsConfig()
+	if err := os.Remove(registry.config_path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Returns the launched agents, in the order they were added.
+func (registry *Registry) Agents() []LaunchedAgent {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	return append([]LaunchedAgent{}, registry.agents...)
+}
+
+// Returns a copy of the records of every agent in the network, including the agents recorded in
+// the config file which were not launched through this registry.
+func (registry *Registry) Records() []AgentRecord {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	records := make([]AgentRecord, 0, len(registry.config.Agents))
+	for _, record := range registry.config.Agents {
+		records = append(records, *record)
+	}
+	return records
+}
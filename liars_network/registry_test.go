@@ -0,0 +1,127 @@
+package liars_network
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// A LaunchedAgent which is never actually launched.
+type fakeAgent struct {
+	port_number int
+}
+
+func (agent *fakeAgent) Stop()                                                     {}
+func (agent *fakeAgent) Kill()                                                     {}
+func (agent *fakeAgent) Reassign(StrategyType, int32, int32, int32, float64) error { return nil }
+func (agent *fakeAgent) IsMatchingAddress(address string) bool {
+	return agent.RetrieveAddress() == address
+}
+func (agent *fakeAgent) RetrievePortNum() string              { return strconv.Itoa(agent.port_number) }
+func (agent *fakeAgent) RetrieveAddress() string              { return "localhost:" + agent.RetrievePortNum() }
+func (agent *fakeAgent) RetrievePid() int                     { return 1 }
+func (agent *fakeAgent) RetrievePublicKey() ed25519.PublicKey { return nil }
+func (agent *fakeAgent) RetrieveMetricsAddress() string       { return "" }
+
+func TestRegistry(t *testing.T) {
+	config_path := filepath.Join(t.TempDir(), "agents.config")
+	registry := NewRegistry(config_path)
+	for _, port_number := range []int{4000, 4001, 4002} {
+		if err := registry.Add(&fakeAgent{port_number}, &AgentRecord{Host: "localhost", Port: port_number}); err != nil {
+			t.Fatalf("Failed to add agent: %s", err)
+		}
+	}
+	agent, is_removed, err := registry.Remove("localhost:4001")
+	if err != nil || !is_removed || agent == nil || !agent.IsMatchingAddress("localhost:4001") {
+		t.Fatalf("Should remove the agent on port 4001, got %v, %v", agent, err)
+	}
+	if _, is_removed, _ := registry.Remove("localhost:4001"); is_removed {
+		t.Errorf("The agent on port 4001 has already been removed")
+	}
+	if err := registry.Update(&fakeAgent{4002}, func(record *AgentRecord) { record.Pid = 42 }); err != nil {
+		t.Fatalf("Failed to update agent: %s", err)
+	}
+
+	// agents.config on disk should reflect every change right away.
+	config, err := ReadAgentsConfig(config_path)
+	if err != nil {
+		t.Fatalf("Failed to read agents.config: %s", err)
+	}
+	if len(config.Agents) != 2 || config.Find("localhost:4000") == nil || config.Find("localhost:4001") != nil ||
+		config.Find("localhost:4002").Pid != 42 {
+		t.Errorf("agents.config diverged from the registry: %+v", config.Agents)
+	}
+	if len(registry.Agents()) != 2 || len(registry.Records()) != 2 {
+		t.Errorf("The registry should hold 2 agents")
+	}
+
+	if err := registry.Clear(); err != nil {
+		t.Fatalf("Failed to clear the registry: %s", err)
+	}
+	if _, err := os.Stat(config_path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("agents.config should be deleted when the registry is cleared")
+	}
+}
+
+func TestResolveAddress(t *testing.T) {
+	registry := NewRegistry(filepath.Join(t.TempDir(), "agents.config"))
+	for _, record := range []*AgentRecord{{Host: "localhost", Port: 4000}, {Host: "10.0.0.2", Port: 4000}, {Host: "10.0.0.2", Port: 4001}} {
+		if err := registry.Add(&fakeAgent{record.Port}, record); err != nil {
+			t.Fatalf("Failed to add agent: %s", err)
+		}
+	}
+	if address, err := registry.ResolveAddress("4001"); err != nil || address != "10.0.0.2:4001" {
+		t.Errorf("Port 4001 should resolve to the only agent listening on it, got %q, %v", address, err)
+	}
+	if address, err := registry.ResolveAddress("10.0.0.2:4000"); err != nil || address != "10.0.0.2:4000" {
+		t.Errorf("An address should resolve to itself, got %q, %v", address, err)
+	}
+	// Agents on different hosts may listen on the same port, in which case the port alone is ambiguous.
+	if _, err := registry.ResolveAddress("4000"); err == nil {
+		t.Errorf("Port 4000 should be ambiguous")
+	}
+	if _, err := registry.ResolveAddress("4002"); err == nil {
+		t.Errorf("No agent listens on port 4002")
+	}
+}
+
+func TestLoadRegistry(t *testing.T) {
+	config_path := filepath.Join(t.TempDir(), "agents.config")
+	// Nothing listens on a port which was closed, as after the agent on it exited.
+	dead_listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	dead_listener.Close()
+	alive_address, dead_address := launchTestAgent(t, 3), dead_listener.Addr().String()
+	config := NewAgentsConfig()
+	for _, address := range []string{alive_address, dead_address} {
+		host, port, _ := net.SplitHostPort(address)
+		port_number, _ := strconv.Atoi(port)
+		config.Add(&AgentRecord{Host: host, Port: port_number})
+	}
+	if err := config.Write(config_path); err != nil {
+		t.Fatalf("Failed to write agents.config: %s", err)
+	}
+
+	registry, err := LoadRegistry(config_path)
+	if err != nil {
+		t.Fatalf("Failed to load the registry: %s", err)
+	}
+	if records := registry.Records(); len(records) != 1 || records[0].Address() != alive_address || len(registry.Agents()) != 0 {
+		t.Fatalf("A loaded registry should only keep the record of the running agent, got %+v", records)
+	}
+	if written_config, err := ReadAgentsConfig(config_path); err != nil || len(written_config.Agents) != 1 {
+		t.Errorf("agents.config should be rewritten without the dead agent, got %v", err)
+	}
+
+	// The agent was launched by another client, so only its record is removed.
+	agent, is_removed, err := registry.Remove(alive_address)
+	if err != nil || !is_removed || agent != nil || len(registry.Records()) != 0 {
+		t.Errorf("Should remove the record of the agent launched by another client, got %v, %v, %v", agent, is_removed, err)
+	}
+}
@@ -0,0 +1,118 @@
+package liars_network
+
+import (
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+// The size of the nonce the client sends along with every query.
+const NONCE_SIZE = 16
+
+// Returns a random nonce for a query.
+func NewNonce() []byte {
+	nonce := make([]byte, NONCE_SIZE)
+	crypto_rand.Read(nonce)
+	return nonce
+}
+
+// The bytes an agent signs to vouch that it answered value to the query with nonce.
+func signedPayload(agent_id string, value int32, nonce []byte) []byte {
+	payload := append([]byte(agent_id), 0)
+	value_bytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(value_bytes, uint32(value))
+	payload = append(payload, value_bytes...)
+	return append(payload, nonce...)
+}
+
+// Signs value as the answer of the agent to the query with nonce.
+func (agent *Agent) sign(value int32, nonce []byte) []byte {
+	return ed25519.Sign(agent.private_key, signedPayload(agent.RetrieveAddress(), value, nonce))
+}
+
+// Whether signature is the signature of the agent with public_key over agent_id, value and nonce.
+func VerifyAgentValue(public_key ed25519.PublicKey, agent_id string, value int32, nonce []byte, signature []byte) bool {
+	if len(public_key) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(public_key, signedPayload(agent_id, value, nonce), signature)
+}
+
+// Verifies the values a proxy agent relayed for the requested_agent_ids it was asked to collect values
+// from. Every requested agent except the unreachable_agent_ids is expected to have exactly one signed
+// value. Returns the values whose signatures verify against public_keys, and the ids of the agents whose
+// values are missing, unsigned, signed by an agent whose public key is unknown or fail to verify, which
+// were forged or tampered with by whoever relayed them. Fails if signed_values or unreachable_agent_ids
+// name an agent which was not requested, or name a requested agent more than once, in which case none
+// of the values can be trusted.
+func VerifySignedValues(signed_values []*SignedValue, requested_agent_ids []string, unreachable_agent_ids []string, nonce []byte,
+	public_keys map[string]ed25519.PublicKey) ([]int32, []string, error) {
+	is_requested := map[string]bool{}
+	for _, agent_id := range requested_agent_ids {
+		is_requested[agent_id] = true
+	}
+	is_reported := map[string]bool{}
+	for _, agent_id := range unreachable_agent_ids {
+		if !is_requested[agent_id] {
+			return nil, nil, fmt.Errorf("agent %s was reported unreachable but was not requested", agent_id)
+		}
+		if is_reported[agent_id] {
+			return nil, nil, fmt.Errorf("agent %s was reported more than once", agent_id)
+		}
+		is_reported[agent_id] = true
+	}
+	agent_id_to_signed_value_map := map[string]*SignedValue{}
+	for _, signed_value := range signed_values {
+		agent_id := signed_value.GetAgentId()
+		if !is_requested[agent_id] {
+			return nil, nil, fmt.Errorf("agent %s was reported but was not requested", agent_id)
+		}
+		if is_reported[agent_id] {
+			return nil, nil, fmt.Errorf("agent %s was reported more than once", agent_id)
+		}
+		is_reported[agent_id] = true
+		agent_id_to_signed_value_map[agent_id] = signed_value
+	}
+	var verified_values []int32
+	var forged_agent_ids []string
+	for _, agent_id := range requested_agent_ids {
+		if containsAddress(unreachable_agent_ids, agent_id) {
+			continue
+		}
+		signed_value, exists := agent_id_to_signed_value_map[agent_id]
+		if !exists || !VerifyAgentValue(public_keys[agent_id], agent_id, signed_value.GetValue(), nonce, signed_value.GetSignature()) {
+			forged_agent_ids = append(forged_agent_ids, agent_id)
+			continue
+		}
+		verified_values = append(verified_values, signed_value.GetValue())
+	}
+	return verified_values, forged_agent_ids, nil
+}
+
+func EncodePublicKey(public_key ed25519.PublicKey) string {
+	return hex.EncodeToString(public_key)
+}
+
+func DecodePublicKey(encoded_public_key string) (ed25519.PublicKey, error) {
+	public_key, err := hex.DecodeString(encoded_public_key)
+	if err != nil {
+		return nil, err
+	}
+	if len(public_key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key is %d bytes long rather than %d", len(public_key), ed25519.PublicKeySize)
+	}
+	return public_key, nil
+}
+
+// Maps the address of every agent in records to its public key, skipping the agents without any.
+func PublicKeysByAddress(records []AgentRecord) map[string]ed25519.PublicKey {
+	public_keys := map[string]ed25519.PublicKey{}
+	for _, record := range records {
+		if public_key, err := DecodePublicKey(record.PublicKey); err == nil {
+			public_keys[record.Address()] = public_key
+		}
+	}
+	return public_keys
+}
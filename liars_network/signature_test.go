@@ -0,0 +1,84 @@
+package liars_network
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSignedLieQuery(t *testing.T) {
+	var wait_group sync.WaitGroup
+	wait_group.Add(1)
+	port_number := make(chan int)
+	proxy_agent := new(Agent)
+	proxy_agent.SetTamperProbability(1)
+	go proxy_agent.Init(port_number, "127.0.0.1:0", &ConstantLie{value: 9}, &wait_group)
+	<-port_number
+	wait_group.Wait()
+	defer proxy_agent.Stop()
+	honest_agent := new(Agent)
+	wait_group.Add(1)
+	go honest_agent.Init(port_number, "127.0.0.1:0", &HonestStrategy{value: 3}, &wait_group)
+	<-port_number
+	wait_group.Wait()
+	defer honest_agent.Stop()
+
+	public_keys := PublicKeysByAddress([]AgentRecord{
+		{Host: "127.0.0.1", Port: proxy_agent.port_number, PublicKey: EncodePublicKey(proxy_agent.RetrievePublicKey())},
+		{Host: "127.0.0.1", Port: honest_agent.port_number, PublicKey: EncodePublicKey(honest_agent.RetrievePublicKey())},
+	})
+	nonce := NewNonce()
+	result := QueryAgent(proxy_agent.RetrieveAddress(),
+		&LieRequest{ExpertMode: true, OtherAgentIds: []string{honest_agent.RetrieveAddress()}, Nonce: nonce}, time.Second)
+	if result.Err != nil {
+		t.Fatalf("Failed to query the proxy agent: %s", result.Err)
+	}
+	if !VerifyAgentValue(public_keys[proxy_agent.RetrieveAddress()], proxy_agent.RetrieveAddress(), 9, nonce, result.Response.Signature) {
+		t.Errorf("The signature of the proxy agent over its own value should verify")
+	}
+	if VerifyAgentValue(public_keys[proxy_agent.RetrieveAddress()], proxy_agent.RetrieveAddress(), 9, NewNonce(), result.Response.Signature) {
+		t.Errorf("The signature of the proxy agent should not verify with another nonce")
+	}
+	// The proxy agent relays 9 rather than 3, which the honest agent did not sign.
+	requested_agent_ids := []string{honest_agent.RetrieveAddress()}
+	values, forged_agent_ids, err := VerifySignedValues(result.Response.SignedValues, requested_agent_ids, nil, nonce, public_keys)
+	if err != nil || len(values) != 0 || len(forged_agent_ids) != 1 || forged_agent_ids[0] != honest_agent.RetrieveAddress() {
+		t.Errorf("The tampered value should fail to verify, got %v, %v, %v", values, forged_agent_ids, err)
+	}
+
+	proxy_agent.SetTamperProbability(0)
+	result = QueryAgent(proxy_agent.RetrieveAddress(),
+		&LieRequest{ExpertMode: true, OtherAgentIds: []string{honest_agent.RetrieveAddress()}, Nonce: nonce}, time.Second)
+	if result.Err != nil {
+		t.Fatalf("Failed to query the proxy agent: %s", result.Err)
+	}
+	signed_values := result.Response.SignedValues
+	if values, forged_agent_ids, err := VerifySignedValues(signed_values, requested_agent_ids, nil, nonce, public_keys); err != nil ||
+		len(values) != 1 || values[0] != 3 || len(forged_agent_ids) != 0 {
+		t.Errorf("The relayed value should verify, got %v, %v, %v", values, forged_agent_ids, err)
+	}
+
+	unsigned_values := []*SignedValue{{AgentId: honest_agent.RetrieveAddress(), Value: 3}}
+	if values, forged_agent_ids, err := VerifySignedValues(unsigned_values, requested_agent_ids, nil, nonce, public_keys); err != nil ||
+		len(values) != 0 || len(forged_agent_ids) != 1 {
+		t.Errorf("An unsigned value should be forged, got %v, %v, %v", values, forged_agent_ids, err)
+	}
+	if values, forged_agent_ids, err := VerifySignedValues(nil, requested_agent_ids, nil, nonce, public_keys); err != nil ||
+		len(values) != 0 || len(forged_agent_ids) != 1 {
+		t.Errorf("A missing value should be forged, got %v, %v, %v", values, forged_agent_ids, err)
+	}
+	if values, forged_agent_ids, err := VerifySignedValues(signed_values, requested_agent_ids, nil, nonce, nil); err != nil ||
+		len(values) != 0 || len(forged_agent_ids) != 1 {
+		t.Errorf("A value of an agent whose public key is unknown should be forged, got %v, %v, %v", values, forged_agent_ids, err)
+	}
+	if _, _, err := VerifySignedValues(signed_values, []string{proxy_agent.RetrieveAddress()}, nil, nonce, public_keys); err == nil {
+		t.Errorf("A value of an agent which was not requested should be rejected")
+	}
+	duplicated_values := append(signed_values, signed_values...)
+	if _, _, err := VerifySignedValues(duplicated_values, requested_agent_ids, nil, nonce, public_keys); err == nil {
+		t.Errorf("Several values of the same agent should be rejected")
+	}
+	if _, _, err := VerifySignedValues(signed_values, requested_agent_ids, requested_agent_ids, nonce, public_keys); err == nil {
+		t.Errorf("A value of an agent reported as unreachable should be rejected")
+	}
+}
@@ -0,0 +1,97 @@
+package liars_network
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// The max number of trials a single simulation runs.
+const MAX_TRIALS_NUM = 1000000
+
+// What a trial of a simulation starts and plays with, the same as the flags of start and play.
+type SimulationParameters struct {
+	NetworkValue int32
+	MaxValue     int32
+	AgentsNum    int
+	LiarRatio    float64
+	Strategy     StrategyType
+	Coalition    CoalitionType
+	DeciderFlags
+}
+
+// The outcomes of the trials of a simulation.
+type SimulationResult struct {
+	TrialsNum int `json:"trials"`
+	// The trials in which the network value was decided.
+	RecoveredNum int `json:"recovered"`
+	// The trials in which no value was decided, as several values were equally likely.
+	TiesNum int `json:"ties"`
+	// The trials in which a value other than the network value was decided.
+	WrongNum int `json:"wrong"`
+	// The number of responses the decisions were made from, over all the trials.
+	ResponsesNum int `json:"responses"`
+}
+
+// The share of the trials in which the network value was recovered.
+func (simulation_result SimulationResult) SuccessRate() float64 {
+	return float64(simulation_result.RecoveredNum) / float64(simulation_result.TrialsNum)
+}
+
+// The share of the trials in which no value was decided.
+func (simulation_result SimulationResult) TieRate() float64 {
+	return float64(simulation_result.TiesNum) / float64(simulation_result.TrialsNum)
+}
+
+// Maps the name of a strategy which a simulation can model to its StrategyType. A flip liar answers
+// honestly until it has been queried MIMIC_QUERIES_BEFORE_FLIP times, which a single play never gets
+// past, so it is not simulated.
+func ParseSimulatedStrategyType(name string) (StrategyType, bool) {
+	strategy_type, exists := ParseStrategyType(name)
+	return strategy_type, exists && strategy_type != MIMIC_THEN_FLIP
+}
+
+// Runs trials_num start/play cycles in this process rather than over gRPC. Every trial assigns the
+// agents their values the same as start does, has every agent answer a single query and decides the
+// network value from the answers the same as play does. The values are drawn from the global generator,
+// so a seeded client runs the same trials.
+func Simulate(parameters SimulationParameters, trials_num int) (SimulationResult, error) {
+	simulation_result := SimulationResult{TrialsNum: trials_num}
+	if parameters.Strategy == MIMIC_THEN_FLIP {
+		return simulation_result, errors.New("flip liars cannot be simulated, as they answer honestly in a single play")
+	}
+	liar_agents_num := int(parameters.LiarRatio * float64(parameters.AgentsNum))
+	decider := NewDecider(parameters.Decider, NetworkAssumptions{HonestAgentsNum: parameters.AgentsNum - liar_agents_num,
+		LiarRatio: parameters.LiarRatio, MaxValue: parameters.MaxValue, Threshold: parameters.Threshold})
+	// Colluding liars pick their values deterministically, so they are the same in every trial.
+	var coalition_values []int32
+	if parameters.Coalition != NO_COALITION {
+		var err error
+		if coalition_values, err = AssignCoalitionValues(parameters.Coalition, parameters.NetworkValue, parameters.MaxValue,
+			parameters.AgentsNum, liar_agents_num); err != nil {
+			return simulation_result, err
+		}
+	}
+	responses := make([]int32, parameters.AgentsNum)
+	for trial := 0; trial < trials_num; trial++ {
+		agent_values := coalition_values
+		if agent_values == nil {
+			agent_values = AssignAgentValues(parameters.Strategy, parameters.NetworkValue, parameters.MaxValue,
+				parameters.AgentsNum, liar_agents_num)
+		}
+		random := NewRandom(rand.Int63())
+		for i, agent_value := range agent_values {
+			responses[i] = NewStrategy(parameters.Strategy, agent_value, parameters.NetworkValue, parameters.MaxValue, random).Answer()
+		}
+		decision := decider.Decide(responses)
+		switch {
+		case !decision.Decided:
+			simulation_result.TiesNum++
+		case decision.Value == parameters.NetworkValue:
+			simulation_result.RecoveredNum++
+		default:
+			simulation_result.WrongNum++
+		}
+		simulation_result.ResponsesNum += len(responses)
+	}
+	return simulation_result, nil
+}
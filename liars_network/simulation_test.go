@@ -0,0 +1,41 @@
+package liars_network
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+	rand.Seed(1)
+	parameters := SimulationParameters{NetworkValue: 3, MaxValue: 10, AgentsNum: 10}
+	// Without liars, the network value is always recovered.
+	simulation_result, err := Simulate(parameters, 100)
+	if err != nil || simulation_result.RecoveredNum != 100 || simulation_result.ResponsesNum != 1000 {
+		t.Errorf("Expected every trial to recover the network value, but got %+v, %v", simulation_result, err)
+	}
+	// A shared coalition as large as the honest agents always ties with them.
+	parameters.LiarRatio, parameters.Coalition = 0.5, SHARED_COALITION
+	if simulation_result, _ := Simulate(parameters, 100); simulation_result.TieRate() != 1 {
+		t.Errorf("Expected every trial to tie, but got %+v", simulation_result)
+	}
+	// Constant liars only tie with the honest agents when as many of them pick the same value.
+	parameters.Coalition = NO_COALITION
+	simulation_result, _ = Simulate(parameters, 1000)
+	if simulation_result.RecoveredNum+simulation_result.TiesNum != 1000 || simulation_result.SuccessRate() < 0.9 {
+		t.Errorf("Expected most trials to recover the network value and the rest to tie, but got %+v", simulation_result)
+	}
+	rand.Seed(1)
+	if replayed_result, _ := Simulate(parameters, 1000); replayed_result != simulation_result {
+		t.Errorf("The same seed should run the same trials, but got %+v and %+v", simulation_result, replayed_result)
+	}
+	parameters.Coalition, parameters.MaxValue = SPLIT_COALITION, 2
+	parameters.LiarRatio = 0.8
+	if _, err := Simulate(parameters, 1); err == nil {
+		t.Errorf("Split liars should fail to collude with a single arbitrary value")
+	}
+	// Flip liars answer honestly in a single play, so whatever a simulation reports about them is meaningless.
+	parameters.Coalition, parameters.Strategy = NO_COALITION, MIMIC_THEN_FLIP
+	if _, err := Simulate(parameters, 1); err == nil {
+		t.Errorf("Flip liars should fail to be simulated")
+	}
+}
@@ -0,0 +1,221 @@
+package liars_network
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+)
+
+type StrategyType int64
+
+const (
+	// Every liar keeps answering with the same arbitrary value.
+	CONSTANT_LIE StrategyType = iota
+	// Every liar answers with a fresh arbitrary value on each query.
+	RANDOM_LIE
+	// All the liars answer with one arbitrary value shared among them.
+	COLLUDING_LIE
+	// Every liar answers with the network value for its first few queries and then
+	// switches to an arbitrary value.
+	MIMIC_THEN_FLIP
+)
+
+// The number of queries a MIMIC_THEN_FLIP liar answers honestly before it starts lying.
+const MIMIC_QUERIES_BEFORE_FLIP = 3
+
+var strategy_names = map[string]StrategyType{
+	"constant": CONSTANT_LIE,
+	"random":   RANDOM_LIE,
+	"collude":  COLLUDING_LIE,
+	"flip":     MIMIC_THEN_FLIP,
+}
+
+// Maps the name used in the start/extend command to its StrategyType.
+func ParseStrategyType(name string) (StrategyType, bool) {
+	strategy_type, exists := strategy_names[name]
+	return strategy_type, exists
+}
+
+func (strategy_type StrategyType) String() string {
+	for name, other_type := range strategy_names {
+		if other_type == strategy_type {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// A Strategy decides the value an agent answers with every time it receives a LieQuery.
+type Strategy interface {
+	Answer() int32
+	// The value the agent holds at the moment, which it proposes and accepts when the agents agree on
+	// the network value. Unlike Answer, it does not count as answering a query.
+	Value() int32
+}
+
+type HonestStrategy struct {
+	value int32
+}
+
+func (strategy *HonestStrategy) Answer() int32 {
+	return strategy.value
+}
+
+func (strategy *HonestStrategy) Value() int32 {
+	return strategy.value
+}
+
+type ConstantLie struct {
+	value int32
+}
+
+func (strategy *ConstantLie) Answer() int32 {
+	return strategy.value
+}
+
+func (strategy *ConstantLie) Value() int32 {
+	return strategy.value
+}
+
+type RandomLie struct {
+	network_value int32
+	max_value     int32
+	random        *rand.Rand
+}
+
+func (strategy *RandomLie) Answer() int32 {
+	return randomLieValue(strategy.random.Int31n, strategy.network_value, strategy.max_value)
+}
+
+// A random liar does not hold on to any value, so it holds 0, which is outside of [1, max_value] and
+// thus never agreed on.
+func (strategy *RandomLie) Value() int32 {
+	return 0
+}
+
+type MimicThenFlip struct {
+	network_value int32
+	value         int32
+	queries_num   int64
+}
+
+func (strategy *MimicThenFlip) Answer() int32 {
+	if atomic.AddInt64(&strategy.queries_num, 1) <= MIMIC_QUERIES_BEFORE_FLIP {
+		return strategy.network_value
+	}
+	return strategy.value
+}
+
+// The value the liar answers the next query with.
+func (strategy *MimicThenFlip) Value() int32 {
+	if atomic.LoadInt64(&strategy.queries_num) < MIMIC_QUERIES_BEFORE_FLIP {
+		return strategy.network_value
+	}
+	return strategy.value
+}
+
+// Returns an arbitrary value x such that 1 <= x <= max_value and x != network_value.
+func RandomLieValue(network_value int32, max_value int32) int32 {
+	return randomLieValue(rand.Int31n, network_value, max_value)
+}
+
+// Same as RandomLieValue, except that the value is drawn through int31n.
+func randomLieValue(int31n func(int32) int32, network_value int32, max_value int32) int32 {
+	var arbitrary_value int32 = 1 + int31n(max_value)
+	// Makes sure there is no collision between network value and arbitrary value.
+	for arbitrary_value == network_value {
+		arbitrary_value = 1 + int31n(max_value)
+	}
+	return arbitrary_value
+}
+
+// Assigns a value to each of the total_agents_num agents in the network. The first liar_agents_num
+// agents are liars and get an arbitrary value; the rest are honest and get the network value.
+func AssignAgentValues(strategy_type StrategyType, network_value int32, max_value int32,
+	total_agents_num int, liar_agents_num int) []int32 {
+	agent_values := make([]int32, total_agents_num)
+	for i := range agent_values {
+		switch {
+		case i >= liar_agents_num:
+			agent_values[i] = network_value
+		case strategy_type == COLLUDING_LIE && i > 0:
+			// All the colluding liars agree on the value picked by the first liar.
+			agent_values[i] = agent_values[0]
+		default:
+			agent_values[i] = RandomLieValue(network_value, max_value)
+		}
+	}
+	return agent_values
+}
+
+// Creates the strategy of an agent which was assigned agent_value. An agent whose value is the
+// network value is honest; otherwise it lies according to strategy_type. Random liars draw their lies
+// from random.
+func NewStrategy(strategy_type StrategyType, agent_value int32, network_value int32, max_value int32, random *rand.Rand) Strategy {
+	if agent_value == network_value {
+		return &HonestStrategy{value: agent_value}
+	}
+	switch strategy_type {
+	case RANDOM_LIE:
+		return &RandomLie{network_value: network_value, max_value: max_value, random: random}
+	case MIMIC_THEN_FLIP:
+		return &MimicThenFlip{network_value: network_value, value: agent_value}
+	default:
+		return &ConstantLie{value: agent_value}
+	}
+}
+
+type CoalitionType int64
+
+const (
+	NO_COALITION CoalitionType = iota
+	// All the liars agree on one shared arbitrary value.
+	SHARED_COALITION
+	// The liars split into groups as large as the number of honest agents, each of which agrees
+	// on its own arbitrary value, so that every full group ties with the honest agents.
+	SPLIT_COALITION
+)
+
+// Maps the name used in the start/extend command to its CoalitionType.
+func ParseCoalitionType(name string) (CoalitionType, bool) {
+	switch name {
+	case "shared":
+		return SHARED_COALITION, true
+	case "split":
+		return SPLIT_COALITION, true
+	}
+	return NO_COALITION, false
+}
+
+// Deterministically assigns a value to each of the total_agents_num agents in the network so that
+// the liars collude according to coalition_type. Same as AssignAgentValues, the first liar_agents_num
+// agents are liars. The arbitrary values are the smallest values in [1, max_value] other than the
+// network value, so the same command always produces the same network. Fails if there are not enough
+// arbitrary values for every group of a SPLIT_COALITION.
+func AssignCoalitionValues(coalition_type CoalitionType, network_value int32, max_value int32,
+	total_agents_num int, liar_agents_num int) ([]int32, error) {
+	group_size := liar_agents_num
+	honest_agents_num := total_agents_num - liar_agents_num
+	if coalition_type == SPLIT_COALITION && honest_agents_num > 0 {
+		group_size = honest_agents_num
+	}
+	var arbitrary_values []int32
+	for value := int32(1); value <= max_value && len(arbitrary_values)*group_size < liar_agents_num; value++ {
+		if value != network_value {
+			arbitrary_values = append(arbitrary_values, value)
+		}
+	}
+	if len(arbitrary_values)*group_size < liar_agents_num {
+		return nil, fmt.Errorf("there are not enough arbitrary values in [1, %d] for %d liars to split into groups of %d", max_value,
+			liar_agents_num, group_size)
+	}
+	agent_values := make([]int32, total_agents_num)
+	for i := range agent_values {
+		if i < liar_agents_num {
+			agent_values[i] = arbitrary_values[i/group_size]
+		} else {
+			agent_values[i] = network_value
+		}
+	}
+	return agent_values, nil
+}
@@ -0,0 +1,110 @@
+package liars_network
+
+import "testing"
+
+func TestAssignAgentValues(t *testing.T) {
+	agent_values := AssignAgentValues(CONSTANT_LIE, 3, 10, 10, 4)
+	if len(agent_values) != 10 {
+		t.Errorf("%v should contain the values of 10 agents", agent_values)
+	}
+	for i, agent_value := range agent_values {
+		if i < 4 && (agent_value == 3 || agent_value < 1 || agent_value > 10) {
+			t.Errorf("Liar agent %v should be assigned an arbitrary value in [1, 10] other than 3, got %v", i, agent_value)
+		}
+		if i >= 4 && agent_value != 3 {
+			t.Errorf("Honest agent %v should be assigned the network value 3, got %v", i, agent_value)
+		}
+	}
+	colluding_values := AssignAgentValues(COLLUDING_LIE, 3, 100, 10, 4)
+	for i := 1; i < 4; i++ {
+		if colluding_values[i] != colluding_values[0] {
+			t.Errorf("%v should have all the colluding liars share one value", colluding_values)
+		}
+	}
+}
+
+func TestNewStrategy(t *testing.T) {
+	if answer := NewStrategy(RANDOM_LIE, 3, 3, 10, NewRandom(1)).Answer(); answer != 3 {
+		t.Errorf("An agent holding the network value should be honest, got %v", answer)
+	}
+	if answer := NewStrategy(CONSTANT_LIE, 7, 3, 10, NewRandom(1)).Answer(); answer != 7 {
+		t.Errorf("A constant liar should answer with its own value, got %v", answer)
+	}
+	random_lie := NewStrategy(RANDOM_LIE, 7, 3, 10, NewRandom(1))
+	for i := 0; i < 100; i++ {
+		if answer := random_lie.Answer(); answer == 3 || answer < 1 || answer > 10 {
+			t.Errorf("A random liar should answer with a value in [1, 10] other than 3, got %v", answer)
+		}
+	}
+	mimic_then_flip := NewStrategy(MIMIC_THEN_FLIP, 7, 3, 10, NewRandom(1))
+	for i := 0; i < MIMIC_QUERIES_BEFORE_FLIP; i++ {
+		if answer := mimic_then_flip.Answer(); answer != 3 {
+			t.Errorf("A flipping liar should mimic the honest agents at first, got %v", answer)
+		}
+	}
+	if answer := mimic_then_flip.Answer(); answer != 7 {
+		t.Errorf("A flipping liar should answer with its own value after flipping, got %v", answer)
+	}
+}
+
+func TestStrategyValue(t *testing.T) {
+	// Reading the value of a flipping liar, as the agreement does, should not bring its flip closer.
+	mimic_then_flip := NewStrategy(MIMIC_THEN_FLIP, 7, 3, 10, NewRandom(1))
+	for i := 0; i < 2*MIMIC_QUERIES_BEFORE_FLIP; i++ {
+		if value := mimic_then_flip.Value(); value != 3 {
+			t.Fatalf("A flipping liar which was never queried should hold the network value, got %v", value)
+		}
+	}
+	for i := 0; i < MIMIC_QUERIES_BEFORE_FLIP; i++ {
+		mimic_then_flip.Answer()
+	}
+	if value := mimic_then_flip.Value(); value != 7 {
+		t.Errorf("A flipping liar should hold its own value once it flips, got %v", value)
+	}
+	if value := NewStrategy(RANDOM_LIE, 7, 3, 10, NewRandom(1)).Value(); value != 0 {
+		t.Errorf("A random liar should hold no value, got %v", value)
+	}
+}
+
+func TestParseStrategyType(t *testing.T) {
+	if strategy_type, valid := ParseStrategyType("collude"); !valid || strategy_type != COLLUDING_LIE {
+		t.Errorf("collude should be parsed as COLLUDING_LIE")
+	}
+	if _, valid := ParseStrategyType("honest"); valid {
+		t.Errorf("honest is not a strategy of liars")
+	}
+	if MIMIC_THEN_FLIP.String() != "flip" {
+		t.Errorf("MIMIC_THEN_FLIP should be named flip")
+	}
+}
+
+func TestAssignCoalitionValues(t *testing.T) {
+	// 6 honest agents and 4 liars sharing one value which is the smallest value other than 1.
+	shared_values, err := AssignCoalitionValues(SHARED_COALITION, 1, 10, 10, 4)
+	if err != nil {
+		t.Errorf("A shared coalition should always be formed, got %s", err)
+	}
+	for i, agent_value := range shared_values {
+		if i < 4 && agent_value != 2 || i >= 4 && agent_value != 1 {
+			t.Errorf("%v should have all the liars share the value 2", shared_values)
+		}
+	}
+	// 3 honest agents and 7 liars split into groups of 3, 3 and 1.
+	split_values, err := AssignCoalitionValues(SPLIT_COALITION, 2, 10, 10, 7)
+	if err != nil {
+		t.Errorf("A split coalition should be formed when there are enough arbitrary values, got %s", err)
+	}
+	expected_values := []int32{1, 1, 1, 3, 3, 3, 4, 2, 2, 2}
+	for i := range expected_values {
+		if split_values[i] != expected_values[i] {
+			t.Errorf("%v should be split as %v", split_values, expected_values)
+			break
+		}
+	}
+	if _, exists := FindNetworkValue(split_values, 3); exists {
+		t.Errorf("%v should fool the client because of the frequency tie", split_values)
+	}
+	if _, err := AssignCoalitionValues(SPLIT_COALITION, 2, 2, 10, 7); err == nil {
+		t.Errorf("A split coalition should not be formed when there is a single arbitrary value")
+	}
+}
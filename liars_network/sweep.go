@@ -0,0 +1,135 @@
+package liars_network
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// The max number of values a grid of a sweep holds.
+const MAX_GRID_VALUES_NUM = 1000
+
+type TableFormat int64
+
+const (
+	CSV_FORMAT TableFormat = iota
+	JSON_FORMAT
+)
+
+// Maps the name used in the sweep command to its TableFormat.
+func ParseTableFormat(name string) (TableFormat, bool) {
+	switch name {
+	case "csv":
+		return CSV_FORMAT, true
+	case "json":
+		return JSON_FORMAT, true
+	}
+	return CSV_FORMAT, false
+}
+
+// The outcomes of the trials simulated at one point of the grids of a sweep.
+type SweepRow struct {
+	LiarRatio   float64 `json:"liar_ratio"`
+	MaxValue    int32   `json:"max_value"`
+	TrialsNum   int     `json:"trials"`
+	SuccessRate float64 `json:"success_rate"`
+	TieRate     float64 `json:"tie_rate"`
+	WrongRate   float64 `json:"wrong_rate"`
+}
+
+// Parses a grid, which is either a comma separated list of values or a range of the form
+// start:stop:step, which includes stop if it is a whole number of steps from start. Every value
+// must be in [min, max].
+func ParseGrid(grid string, min float64, max float64) ([]float64, error) {
+	var values []float64
+	if bounds := strings.Split(grid, ":"); len(bounds) == 3 {
+		var numbers [3]float64
+		for i, bound := range bounds {
+			number, err := strconv.ParseFloat(bound, 64)
+			if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+				return nil, fmt.Errorf("%q is not a number", bound)
+			}
+			numbers[i] = number
+		}
+		start, stop, step := numbers[0], numbers[1], numbers[2]
+		if step <= 0 || stop < start {
+			return nil, fmt.Errorf("%q should go up from start to stop by a positive step", grid)
+		}
+		if (stop-start)/step >= MAX_GRID_VALUES_NUM {
+			return nil, fmt.Errorf("%q holds more than %d values", grid, MAX_GRID_VALUES_NUM)
+		}
+		// Every value is computed from start rather than accumulated, and rounded, so that 0:1:0.1
+		// reaches 1 rather than stopping at 0.9999999999999999.
+		for i := 0; ; i++ {
+			value := math.Round((start+float64(i)*step)*1e9) / 1e9
+			if value > stop {
+				break
+			}
+			values = append(values, value)
+		}
+	} else {
+		for _, element := range strings.Split(grid, ",") {
+			value, err := strconv.ParseFloat(element, 64)
+			if err != nil || math.IsNaN(value) {
+				return nil, fmt.Errorf("%q is not a number", element)
+			}
+			values = append(values, value)
+		}
+		if len(values) > MAX_GRID_VALUES_NUM {
+			return nil, fmt.Errorf("%q holds more than %d values", grid, MAX_GRID_VALUES_NUM)
+		}
+	}
+	for _, value := range values {
+		if value < min || value > max {
+			return nil, fmt.Errorf("%s is out of the range of %s", strconv.FormatFloat(value, 'f', -1, 64), formatRange(min, max, false))
+		}
+	}
+	return values, nil
+}
+
+// Simulates trials_num trials at every pair of a liar ratio in liar_ratios and a max value in max_values,
+// the rest of the parameters being the same. The rows are ordered by max value first, so that every
+// max value makes one curve over the liar ratios.
+func Sweep(parameters SimulationParameters, liar_ratios []float64, max_values []int32, trials_num int) ([]SweepRow, error) {
+	var rows []SweepRow
+	for _, max_value := range max_values {
+		for _, liar_ratio := range liar_ratios {
+			parameters.LiarRatio, parameters.MaxValue = liar_ratio, max_value
+			simulation_result, err := Simulate(parameters, trials_num)
+			if err != nil {
+				return nil, fmt.Errorf("at liar ratio %g and max value %d: %w", liar_ratio, max_value, err)
+			}
+			rows = append(rows, SweepRow{LiarRatio: liar_ratio, MaxValue: max_value, TrialsNum: trials_num,
+				SuccessRate: simulation_result.SuccessRate(), TieRate: simulation_result.TieRate(),
+				WrongRate: float64(simulation_result.WrongNum) / float64(trials_num)})
+		}
+	}
+	return rows, nil
+}
+
+// Writes rows as a table in table_format, which is a CSV file with a header or a JSON array.
+func WriteSweepTable(writer io.Writer, rows []SweepRow, table_format TableFormat) error {
+	if table_format == JSON_FORMAT {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rows)
+	}
+	csv_writer := csv.NewWriter(writer)
+	csv_writer.Write([]string{"liar_ratio", "max_value", "trials", "success_rate", "tie_rate", "wrong_rate"})
+	for _, row := range rows {
+		csv_writer.Write([]string{
+			strconv.FormatFloat(row.LiarRatio, 'f', -1, 64),
+			strconv.FormatInt(int64(row.MaxValue), 10),
+			strconv.Itoa(row.TrialsNum),
+			strconv.FormatFloat(row.SuccessRate, 'f', -1, 64),
+			strconv.FormatFloat(row.TieRate, 'f', -1, 64),
+			strconv.FormatFloat(row.WrongRate, 'f', -1, 64),
+		})
+	}
+	csv_writer.Flush()
+	return csv_writer.Error()
+}
@@ -0,0 +1,60 @@
+package liars_network
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestParseGrid(t *testing.T) {
+	grids := map[string][]float64{
+		"0:1:0.1":     {0, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1},
+		"0:0.5:0.2":   {0, 0.2, 0.4},
+		"0.4,0.1,0.3": {0.4, 0.1, 0.3},
+		"2":           {2},
+	}
+	for grid, expected_values := range grids {
+		values, err := ParseGrid(grid, 0, 2)
+		if err != nil || len(values) != len(expected_values) {
+			t.Errorf("Expected %q to be parsed as %v, but got %v, %v", grid, expected_values, values, err)
+			continue
+		}
+		for i, value := range values {
+			if value != expected_values[i] {
+				t.Errorf("Expected %q to be parsed as %v, but got %v", grid, expected_values, values)
+				break
+			}
+		}
+	}
+	for _, grid := range []string{"0:1", "1:0:0.1", "0:1:0", "0:1:0.0001", "0.1,,0.2", "0,3", "a:1:0.1"} {
+		if _, err := ParseGrid(grid, 0, 2); err == nil {
+			t.Errorf("%q should be invalid", grid)
+		}
+	}
+}
+
+func TestSweep(t *testing.T) {
+	rand.Seed(1)
+	parameters := SimulationParameters{NetworkValue: 1, AgentsNum: 10}
+	rows, err := Sweep(parameters, []float64{0, 0.5}, []int32{2, 100}, 200)
+	if err != nil || len(rows) != 4 {
+		t.Fatalf("Expected a row for each of the 4 points of the grids, but got %v, %v", rows, err)
+	}
+	// With a single arbitrary value, as many liars as honest agents always tie with them.
+	if rows[0].SuccessRate != 1 || rows[1].MaxValue != 2 || rows[1].TieRate != 1 {
+		t.Errorf("Unexpected rows for the max value 2: %+v", rows[:2])
+	}
+	if rows[2].MaxValue != 100 || rows[3].LiarRatio != 0.5 || rows[3].SuccessRate < 0.9 {
+		t.Errorf("Unexpected rows for the max value 100: %+v", rows[2:])
+	}
+	var table bytes.Buffer
+	if err := WriteSweepTable(&table, rows, CSV_FORMAT); err != nil {
+		t.Fatalf("Failed to write the table: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	if len(lines) != 5 || lines[0] != "liar_ratio,max_value,trials,success_rate,tie_rate,wrong_rate" ||
+		lines[2] != "0.5,2,200,0,1,0" {
+		t.Errorf("Unexpected CSV table:\n%s", table.String())
+	}
+}
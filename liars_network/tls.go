@@ -0,0 +1,307 @@
+package liars_network
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	crypto_rand "crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	AUTHORITY_CERTIFICATE_FILE = "ca.pem"
+	AUTHORITY_KEY_FILE         = "ca-key.pem"
+	// How long the certificates issued by a CertificateAuthority, and the authority itself, are valid.
+	CERTIFICATE_VALIDITY = 365 * 24 * time.Hour
+	// The scheme of the URI which binds the certificate of an agent to the address it is reached through.
+	AGENT_IDENTITY_SCHEME = "liarslie"
+)
+
+// The TLS config every agent is dialed with, or nil to dial the agents without TLS. The client and the
+// agents in the same process dial concurrently, so it is guarded by dial_config_mutex.
+var (
+	dial_config_mutex sync.RWMutex
+	dial_config       *tls.Config
+)
+
+// Sets the TLS config every agent is dialed with from this process, by the client as well as by the
+// agents themselves. A nil config dials the agents without TLS.
+func SetDialConfig(config *tls.Config) {
+	dial_config_mutex.Lock()
+	defer dial_config_mutex.Unlock()
+	dial_config = config
+}
+
+// Returns the credentials the agent at address is dialed with. Over TLS, only the agent whose certificate
+// was issued for address is accepted, so that an agent cannot stand in for another one.
+func dialCredentials(address string) credentials.TransportCredentials {
+	dial_config_mutex.RLock()
+	config := dial_config
+	dial_config_mutex.RUnlock()
+	if config == nil {
+		return insecure.NewCredentials()
+	}
+	address_config := config.Clone()
+	address_config.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 || !IsAgentCertificate(state.PeerCertificates[0], address) {
+			return fmt.Errorf("the certificate of the agent was not issued for %s", address)
+		}
+		return nil
+	}
+	return credentials.NewTLS(address_config)
+}
+
+// Whether certificate was issued to the agent reached through address.
+func IsAgentCertificate(certificate *x509.Certificate, address string) bool {
+	identity := agentIdentity(address)
+	for _, uri := range certificate.URIs {
+		if uri.String() == identity.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// The URI which identifies the agent reached through address in its certificate. An address without a
+// host is reached through localhost.
+func agentIdentity(address string) *url.URL {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return &url.URL{Scheme: AGENT_IDENTITY_SCHEME, Opaque: address}
+	}
+	return &url.URL{Scheme: AGENT_IDENTITY_SCHEME, Host: net.JoinHostPort(ReachableHost(host), port)}
+}
+
+// The files of a certificate issued by a CertificateAuthority, along with the certificate of the
+// authority itself, which is all an agent or the client needs to authenticate each other over mTLS.
+type TLSFiles struct {
+	CertificatePath string
+	KeyPath         string
+	AuthorityPath   string
+}
+
+// A local certificate authority which issues the certificates of the client and of every agent, so that
+// only the processes holding one of its certificates can take part in the network.
+type CertificateAuthority struct {
+	dir         string
+	certificate *x509.Certificate
+	private_key *ecdsa.PrivateKey
+}
+
+// Loads the certificate authority stored in dir, or creates it if there is none yet.
+func LoadOrCreateCertificateAuthority(dir string) (*CertificateAuthority, error) {
+	authority := &CertificateAuthority{dir: dir}
+	certificate_pem, err := os.ReadFile(filepath.Join(dir, AUTHORITY_CERTIFICATE_FILE))
+	if errors.Is(err, os.ErrNotExist) {
+		return authority, authority.create()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if authority.certificate, err = parseCertificatePEM(certificate_pem); err != nil {
+		return nil, err
+	}
+	key_pem, err := os.ReadFile(filepath.Join(dir, AUTHORITY_KEY_FILE))
+	if err != nil {
+		return nil, err
+	}
+	key_block, _ := pem.Decode(key_pem)
+	if key_block == nil {
+		return nil, fmt.Errorf("%s is not PEM encoded", AUTHORITY_KEY_FILE)
+	}
+	if authority.private_key, err = x509.ParseECPrivateKey(key_block.Bytes); err != nil {
+		return nil, err
+	}
+	return authority, nil
+}
+
+func (authority *CertificateAuthority) create() error {
+	if err := os.MkdirAll(authority.dir, 0700); err != nil {
+		return err
+	}
+	private_key, err := ecdsa.GenerateKey(elliptic.P256(), crypto_rand.Reader)
+	if err != nil {
+		return err
+	}
+	template, err := newCertificateTemplate("liarslie CA")
+	if err != nil {
+		return err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	certificate_der, err := x509.CreateCertificate(crypto_rand.Reader, template, template, &private_key.PublicKey, private_key)
+	if err != nil {
+		return err
+	}
+	if authority.certificate, err = x509.ParseCertificate(certificate_der); err != nil {
+		return err
+	}
+	authority.private_key = private_key
+	return authority.writeKeyPair(AUTHORITY_CERTIFICATE_FILE, AUTHORITY_KEY_FILE, certificate_der, private_key)
+}
+
+// Issues a certificate to name for ext_key_usages, valid for hosts and identifying its holder by
+// identities. The certificate and its key are written to dir as name.pem and name-key.pem.
+func (authority *CertificateAuthority) issue(name string, hosts []string, identities []*url.URL,
+	ext_key_usages ...x509.ExtKeyUsage) (*TLSFiles, error) {
+	private_key, err := ecdsa.GenerateKey(elliptic.P256(), crypto_rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template, err := newCertificateTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = ext_key_usages
+	template.URIs = identities
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	certificate_der, err := x509.CreateCertificate(crypto_rand.Reader, template, authority.certificate, &private_key.PublicKey,
+		authority.private_key)
+	if err != nil {
+		return nil, err
+	}
+	if err := authority.writeKeyPair(name+".pem", name+"-key.pem", certificate_der, private_key); err != nil {
+		return nil, err
+	}
+	return &TLSFiles{CertificatePath: filepath.Join(authority.dir, name+".pem"), KeyPath: filepath.Join(authority.dir, name+"-key.pem"),
+		AuthorityPath: filepath.Join(authority.dir, AUTHORITY_CERTIFICATE_FILE)}, nil
+}
+
+// Issues the certificate of the client, which authenticates it to the agents but cannot serve as an agent.
+func (authority *CertificateAuthority) IssueClientCertificate() (*TLSFiles, error) {
+	return authority.issue("client", nil, nil, x509.ExtKeyUsageClientAuth)
+}
+
+// Issues the certificate of an agent bound to bind_host which listens on port_number. The certificate
+// authenticates the agent both when it serves and when it dials the other agents, and is only accepted
+// from the agent reached through that port.
+func (authority *CertificateAuthority) IssueAgentCertificate(bind_host string, port_number int) (*TLSFiles, error) {
+	var identities []*url.URL
+	for _, host := range TLSHosts(bind_host) {
+		identities = append(identities, agentIdentity(net.JoinHostPort(host, strconv.Itoa(port_number))))
+	}
+	return authority.issue(agentCertificateName(net.JoinHostPort(ReachableHost(bind_host), strconv.Itoa(port_number))),
+		TLSHosts(bind_host), identities, x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth)
+}
+
+// Deletes the certificate of the agent reached through address, once the agent left the network.
+func (authority *CertificateAuthority) RemoveAgentCertificate(address string) error {
+	name := agentCertificateName(address)
+	for _, file := range []string{name + ".pem", name + "-key.pem"} {
+		if err := os.Remove(filepath.Join(authority.dir, file)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Names the certificate of the agent reached through address after it, e.g. agent-localhost-4000.
+func agentCertificateName(address string) string {
+	return "agent-" + strings.NewReplacer(":", "-", "[", "", "]", "").Replace(address)
+}
+
+// The hosts the certificate of an agent bound to bind_host needs to be valid for.
+func TLSHosts(bind_host string) []string {
+	if reachable_host := ReachableHost(bind_host); reachable_host != "localhost" {
+		return []string{reachable_host}
+	}
+	return []string{"localhost", "127.0.0.1", "::1"}
+}
+
+func newCertificateTemplate(name string) (*x509.Certificate, error) {
+	serial_number, err := crypto_rand.Int(crypto_rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	return &x509.Certificate{
+		SerialNumber: serial_number,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(CERTIFICATE_VALIDITY),
+	}, nil
+}
+
+// Writes a certificate and its private key, which only the owner can read, to the dir of the authority.
+func (authority *CertificateAuthority) writeKeyPair(certificate_file string, key_file string, certificate_der []byte,
+	private_key *ecdsa.PrivateKey) error {
+	key_der, err := x509.MarshalECPrivateKey(private_key)
+	if err != nil {
+		return err
+	}
+	certificate_pem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate_der})
+	if err := os.WriteFile(filepath.Join(authority.dir, certificate_file), certificate_pem, 0644); err != nil {
+		return err
+	}
+	key_pem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key_der})
+	return os.WriteFile(filepath.Join(authority.dir, key_file), key_pem, 0600)
+}
+
+func parseCertificatePEM(certificate_pem []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certificate_pem)
+	if block == nil {
+		return nil, errors.New("certificate is not PEM encoded")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// Loads the key pair in files along with the pool of the certificate of the authority which issued it.
+func loadTLSFiles(files *TLSFiles) (tls.Certificate, *x509.CertPool, error) {
+	key_pair, err := tls.LoadX509KeyPair(files.CertificatePath, files.KeyPath)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	authority_pem, err := os.ReadFile(files.AuthorityPath)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	authority_pool := x509.NewCertPool()
+	if !authority_pool.AppendCertsFromPEM(authority_pem) {
+		return tls.Certificate{}, nil, fmt.Errorf("%s does not contain any certificate", files.AuthorityPath)
+	}
+	return key_pair, authority_pool, nil
+}
+
+// The credentials an agent serves with, which only accept clients holding a certificate issued by the
+// same authority.
+func NewServerCredentials(files *TLSFiles) (credentials.TransportCredentials, error) {
+	key_pair, authority_pool, err := loadTLSFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{key_pair}, ClientCAs: authority_pool,
+		ClientAuth: tls.RequireAndVerifyClientCert, MinVersion: tls.VersionTLS12}), nil
+}
+
+// The TLS config agents are dialed with, which only accepts agents holding a certificate issued by the
+// same authority.
+func NewDialConfig(files *TLSFiles) (*tls.Config, error) {
+	key_pair, authority_pool, err := loadTLSFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{key_pair}, RootCAs: authority_pool, MinVersion: tls.VersionTLS12}, nil
+}
@@ -0,0 +1,144 @@
+package liars_network
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Launches an agent serving with a certificate issued by authority on a free port of localhost, and
+// returns it.
+func launchTLSAgent(t *testing.T, authority *CertificateAuthority) (*Agent, *TLSFiles) {
+	port, err := AllocateFreePort("127.0.0.1")
+	if err != nil {
+		t.Fatalf("Failed to allocate a port: %s", err)
+	}
+	agent_files, err := authority.IssueAgentCertificate("127.0.0.1", port)
+	if err != nil {
+		t.Fatalf("Failed to issue the certificate of the agent: %s", err)
+	}
+	server_credentials, err := NewServerCredentials(agent_files)
+	if err != nil {
+		t.Fatalf("Failed to load the certificate of the agent: %s", err)
+	}
+	var wait_group sync.WaitGroup
+	wait_group.Add(1)
+	port_number := make(chan int)
+	agent := new(Agent)
+	agent.SetServerCredentials(server_credentials)
+	go agent.Init(port_number, net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), &HonestStrategy{value: 3}, &wait_group)
+	<-port_number
+	wait_group.Wait()
+	t.Cleanup(agent.Stop)
+	return agent, agent_files
+}
+
+// Has this process dial the agents with the certificate in files until the test is over.
+func dialWith(t *testing.T, files *TLSFiles) {
+	dial_config, err := NewDialConfig(files)
+	if err != nil {
+		t.Fatalf("Failed to load the certificate to dial with: %s", err)
+	}
+	SetDialConfig(dial_config)
+	t.Cleanup(func() { SetDialConfig(nil) })
+}
+
+func TestMutualTLS(t *testing.T) {
+	authority, err := LoadOrCreateCertificateAuthority(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create the CA: %s", err)
+	}
+	agent, agent_files := launchTLSAgent(t, authority)
+
+	if result := QueryAgent(agent.RetrieveAddress(), new(LieRequest), time.Second); result.Err == nil {
+		t.Errorf("The agent should refuse clients without TLS")
+	}
+
+	// A client holding a certificate issued by another CA is refused as well.
+	other_authority, err := LoadOrCreateCertificateAuthority(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create the other CA: %s", err)
+	}
+	other_files, _ := other_authority.IssueClientCertificate()
+	dialWith(t, &TLSFiles{CertificatePath: other_files.CertificatePath, KeyPath: other_files.KeyPath,
+		AuthorityPath: agent_files.AuthorityPath})
+	if result := QueryAgent(agent.RetrieveAddress(), new(LieRequest), time.Second); result.Err == nil {
+		t.Errorf("The agent should refuse clients whose certificates were issued by another CA")
+	}
+
+	client_files, err := authority.IssueClientCertificate()
+	if err != nil {
+		t.Fatalf("Failed to issue the certificate of the client: %s", err)
+	}
+	dialWith(t, client_files)
+	if result := QueryAgent(agent.RetrieveAddress(), new(LieRequest), time.Second); result.Err != nil || result.Response.AgentValue != 3 {
+		t.Errorf("The agent should answer 3 over mTLS, got %+v", result)
+	}
+
+	// The CA is loaded back from its directory rather than created again.
+	loaded_authority, err := LoadOrCreateCertificateAuthority(authority.dir)
+	if err != nil || !loaded_authority.certificate.Equal(authority.certificate) {
+		t.Errorf("Should load the same CA, got %v", err)
+	}
+}
+
+func TestAgentIdentity(t *testing.T) {
+	authority, err := LoadOrCreateCertificateAuthority(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create the CA: %s", err)
+	}
+	agent, agent_files := launchTLSAgent(t, authority)
+	other_agent, _ := launchTLSAgent(t, authority)
+	client_files, err := authority.IssueClientCertificate()
+	if err != nil {
+		t.Fatalf("Failed to issue the certificate of the client: %s", err)
+	}
+	dialWith(t, client_files)
+
+	certificate_pem, err := os.ReadFile(agent_files.CertificatePath)
+	if err != nil {
+		t.Fatalf("Failed to read the certificate of the agent: %s", err)
+	}
+	certificate, err := parseCertificatePEM(certificate_pem)
+	if err != nil || !IsAgentCertificate(certificate, agent.RetrieveAddress()) ||
+		IsAgentCertificate(certificate, other_agent.RetrieveAddress()) {
+		t.Errorf("The certificate of the agent should only be issued for %s, got %v", agent.RetrieveAddress(), err)
+	}
+	if result := QueryAgent(other_agent.RetrieveAddress(), new(LieRequest), time.Second); result.Err != nil {
+		t.Errorf("The other agent should answer over mTLS, got %+v", result)
+	}
+
+	// An agent serving with the certificate of another agent, or with the certificate of the client, is
+	// refused even though the CA issued the certificate.
+	for _, files := range []*TLSFiles{agent_files, client_files} {
+		server_credentials, err := NewServerCredentials(files)
+		if err != nil {
+			t.Fatalf("Failed to load the certificate: %s", err)
+		}
+		var wait_group sync.WaitGroup
+		wait_group.Add(1)
+		port_number := make(chan int)
+		impostor_agent := new(Agent)
+		impostor_agent.SetServerCredentials(server_credentials)
+		go impostor_agent.Init(port_number, "127.0.0.1:0", &HonestStrategy{value: 3}, &wait_group)
+		<-port_number
+		wait_group.Wait()
+		t.Cleanup(impostor_agent.Stop)
+		if result := QueryAgent(impostor_agent.RetrieveAddress(), new(LieRequest), time.Second); result.Err == nil {
+			t.Errorf("An agent serving with %s should be refused", files.CertificatePath)
+		}
+	}
+
+	if err := authority.RemoveAgentCertificate(agent.RetrieveAddress()); err != nil {
+		t.Fatalf("Failed to remove the certificate of the agent: %s", err)
+	}
+	for _, path := range []string{agent_files.CertificatePath, agent_files.KeyPath} {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s should be deleted along with the agent", path)
+		}
+	}
+}
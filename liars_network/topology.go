@@ -0,0 +1,162 @@
+package liars_network
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+type TopologyType int64
+
+const (
+	// Every agent is connected to the degree/2 nearest agents on each side of a ring.
+	RING_TOPOLOGY TopologyType = iota
+	// Every agent is connected to degree agents picked at random.
+	REGULAR_TOPOLOGY
+	// A ring where every connection is rewired to a random agent with some probability, as in the
+	// Watts-Strogatz model.
+	SMALL_WORLD_TOPOLOGY
+)
+
+// The probability with which every connection of a smallworld topology is rewired by default.
+const DEFAULT_REWIRE_PROBABILITY = 0.1
+
+// How many times BuildTopology starts a random regular topology over before giving up.
+const REGULAR_TOPOLOGY_ATTEMPTS_NUM = 100
+
+var topology_names = map[string]TopologyType{
+	"ring":       RING_TOPOLOGY,
+	"regular":    REGULAR_TOPOLOGY,
+	"smallworld": SMALL_WORLD_TOPOLOGY,
+}
+
+// Maps the name used in the topology command to its TopologyType.
+func ParseTopologyType(name string) (TopologyType, bool) {
+	topology_type, exists := topology_names[name]
+	return topology_type, exists
+}
+
+func (topology_type TopologyType) String() string {
+	for name, other_type := range topology_names {
+		if other_type == topology_type {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// Connects agents_num agents, so that each of them has degree neighbors. rewire_probability only
+// applies to SMALL_WORLD_TOPOLOGY. Returns the indices of the neighbors of every agent, in ascending
+// order. Fails if no such topology exists.
+func BuildTopology(topology_type TopologyType, agents_num int, degree int, rewire_probability float64) ([][]int, error) {
+	if degree < 2 || degree >= agents_num {
+		return nil, fmt.Errorf("degree must be >= 2 and < the number of running agents, which is %d", agents_num)
+	}
+	adjacency := make([]map[int]bool, agents_num)
+	for i := range adjacency {
+		adjacency[i] = map[int]bool{}
+	}
+	switch topology_type {
+	case REGULAR_TOPOLOGY:
+		if agents_num*degree%2 != 0 {
+			return nil, errors.New("degree must be even when the number of running agents is odd")
+		}
+		if !connectRegular(adjacency, degree) {
+			return nil, fmt.Errorf("failed to connect the agents in a random regular topology of degree %d", degree)
+		}
+	default:
+		if degree%2 != 0 {
+			return nil, errors.New("degree must be even for ring and smallworld topologies")
+		}
+		for i := 0; i < agents_num; i++ {
+			for distance := 1; distance <= degree/2; distance++ {
+				connect(adjacency, i, (i+distance)%agents_num)
+			}
+		}
+		if topology_type == SMALL_WORLD_TOPOLOGY {
+			rewire(adjacency, degree, rewire_probability)
+		}
+	}
+	neighbors := make([][]int, agents_num)
+	for i, adjacent := range adjacency {
+		for j := range adjacent {
+			neighbors[i] = append(neighbors[i], j)
+		}
+		sort.Ints(neighbors[i])
+	}
+	return neighbors, nil
+}
+
+// Pairs up degree connection stubs of every agent at random, avoiding loops and duplicate connections.
+// Whenever the remaining stubs cannot be paired up, starts over.
+func connectRegular(adjacency []map[int]bool, degree int) bool {
+	for attempt := 0; attempt < REGULAR_TOPOLOGY_ATTEMPTS_NUM; attempt++ {
+		for i := range adjacency {
+			adjacency[i] = map[int]bool{}
+		}
+		var stubs []int
+		for i := range adjacency {
+			for j := 0; j < degree; j++ {
+				stubs = append(stubs, i)
+			}
+		}
+		for len(stubs) != 0 {
+			is_paired := false
+			for try := 0; try < len(stubs)*len(stubs) && !is_paired; try++ {
+				i, j := rand.Intn(len(stubs)), rand.Intn(len(stubs))
+				if stubs[i] == stubs[j] || adjacency[stubs[i]][stubs[j]] {
+					continue
+				}
+				connect(adjacency, stubs[i], stubs[j])
+				// Removes the larger index first, so that the smaller one still points to its stub.
+				if i < j {
+					i, j = j, i
+				}
+				stubs[i] = stubs[len(stubs)-1]
+				stubs = stubs[:len(stubs)-1]
+				stubs[j] = stubs[len(stubs)-1]
+				stubs = stubs[:len(stubs)-1]
+				is_paired = true
+			}
+			if !is_paired {
+				break
+			}
+		}
+		if len(stubs) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Rewires every connection of the ring to its degree/2 next agents with rewire_probability, replacing
+// the next agent with a random agent which is not connected yet.
+func rewire(adjacency []map[int]bool, degree int, rewire_probability float64) {
+	agents_num := len(adjacency)
+	for i := 0; i < agents_num; i++ {
+		for distance := 1; distance <= degree/2; distance++ {
+			j := (i + distance) % agents_num
+			if !adjacency[i][j] || rand.Float64() >= rewire_probability {
+				continue
+			}
+			var candidates []int
+			for k := 0; k < agents_num; k++ {
+				if k != i && !adjacency[i][k] {
+					candidates = append(candidates, k)
+				}
+			}
+			if len(candidates) == 0 {
+				continue
+			}
+			delete(adjacency[i], j)
+			delete(adjacency[j], i)
+			connect(adjacency, i, candidates[rand.Intn(len(candidates))])
+		}
+	}
+}
+
+func connect(adjacency []map[int]bool, i int, j int) {
+	adjacency[i][j] = true
+	adjacency[j][i] = true
+}
@@ -0,0 +1,71 @@
+package liars_network
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Checks that every agent has degree neighbors, none of which is itself, and that every connection goes both ways.
+func checkTopology(t *testing.T, neighbors [][]int, degree int) {
+	for i, adjacent := range neighbors {
+		if len(adjacent) != degree {
+			t.Errorf("Agent %d should have %d neighbors, got %v", i, degree, adjacent)
+		}
+		for _, j := range adjacent {
+			if j == i {
+				t.Errorf("Agent %d should not be its own neighbor", i)
+			}
+			is_symmetric := false
+			for _, k := range neighbors[j] {
+				is_symmetric = is_symmetric || k == i
+			}
+			if !is_symmetric {
+				t.Errorf("Agent %d is a neighbor of agent %d, but not the other way around", j, i)
+			}
+		}
+	}
+}
+
+func TestBuildTopology(t *testing.T) {
+	ring, err := BuildTopology(RING_TOPOLOGY, 6, 2, 0)
+	if err != nil {
+		t.Fatalf("A ring of 6 agents of degree 2 should be valid")
+	}
+	if expected := [][]int{{1, 5}, {0, 2}, {1, 3}, {2, 4}, {3, 5}, {0, 4}}; !reflect.DeepEqual(ring, expected) {
+		t.Errorf("The ring should be %v, got %v", expected, ring)
+	}
+
+	regular, err := BuildTopology(REGULAR_TOPOLOGY, 20, 3, 0)
+	if err != nil {
+		t.Fatalf("A regular topology of 20 agents of degree 3 should be valid")
+	}
+	checkTopology(t, regular, 3)
+
+	// Without any rewiring, a small world is a ring.
+	small_world, err := BuildTopology(SMALL_WORLD_TOPOLOGY, 10, 4, 0)
+	if ring, _ := BuildTopology(RING_TOPOLOGY, 10, 4, 0); err != nil || !reflect.DeepEqual(small_world, ring) {
+		t.Errorf("A small world which is not rewired should be a ring, got %v", small_world)
+	}
+	small_world, err = BuildTopology(SMALL_WORLD_TOPOLOGY, 10, 4, 1)
+	if err != nil {
+		t.Fatalf("A small world of 10 agents of degree 4 should be valid")
+	}
+	edges_num := 0
+	for _, adjacent := range small_world {
+		edges_num += len(adjacent)
+	}
+	if edges_num != 10*4 {
+		t.Errorf("Rewiring should keep the number of connections, got %v", small_world)
+	}
+
+	for _, invalid := range []struct {
+		topology_type TopologyType
+		agents_num    int
+		degree        int
+	}{{RING_TOPOLOGY, 5, 5}, {RING_TOPOLOGY, 5, 3}, {REGULAR_TOPOLOGY, 5, 3}, {SMALL_WORLD_TOPOLOGY, 5, 0}, {REGULAR_TOPOLOGY, 6, 1}} {
+		if _, err := BuildTopology(invalid.topology_type, invalid.agents_num, invalid.degree, 0); err == nil {
+			t.Errorf("A %s topology of %d agents of degree %d should be invalid", invalid.topology_type,
+				invalid.agents_num, invalid.degree)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package liars_network
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// The types of the entries of a transcript. Every session of a client starts with a session entry,
+// and every command with a command entry, followed by the agents it launched or reassigned, the
+// responses it gathered and finally its result.
+const (
+	SESSION_ENTRY  = "session"
+	COMMAND_ENTRY  = "command"
+	AGENT_ENTRY    = "agent"
+	RESPONSE_ENTRY = "response"
+	RESULT_ENTRY   = "result"
+)
+
+// One line of a transcript. Only the field matching Type is set.
+type TranscriptEntry struct {
+	Type     string              `json:"type"`
+	Time     time.Time           `json:"time"`
+	Session  *TranscriptSession  `json:"session,omitempty"`
+	Command  string              `json:"command,omitempty"`
+	Agent    *TranscriptAgent    `json:"agent,omitempty"`
+	Response *TranscriptResponse `json:"response,omitempty"`
+	// The result of the command, in whatever form the client writes it.
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+// What a client was started with, which a replay needs to draw the same random numbers.
+type TranscriptSession struct {
+	Seed int64  `json:"seed"`
+	Mode string `json:"mode"`
+	// The range of ports the agents were launched over, if any.
+	Ports string `json:"ports,omitempty"`
+	// Whether the agents were launched as separate processes, and over mTLS.
+	Process bool `json:"process,omitempty"`
+	TLS     bool `json:"tls,omitempty"`
+	// The host the agents were bound to. Empty in transcripts which predate it.
+	Host string `json:"host,omitempty"`
+}
+
+type TranscriptAgent struct {
+	Address           string  `json:"address"`
+	Value             int32   `json:"value"`
+	Role              string  `json:"role"`
+	Strategy          string  `json:"strategy"`
+	TamperProbability float64 `json:"tamper_probability,omitempty"`
+	// Whether the agent was already running and had its value updated by extend, rather than launched.
+	IsReassigned bool `json:"is_reassigned,omitempty"`
+}
+
+// The response of an agent to a LieQuery, or the error querying it failed with.
+type TranscriptResponse struct {
+	Address     string          `json:"address"`
+	LieResponse json.RawMessage `json:"lie_response,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// A file which entries are appended to, one JSON object per line, and never rewritten.
+type Transcript struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// Opens the transcript at path for appending, creating it if there is none yet.
+func OpenTranscript(path string) (*Transcript, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Transcript{file: file}, nil
+}
+
+// Appends entry, timestamping it if it is not yet.
+func (transcript *Transcript) Record(entry TranscriptEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	transcript.mutex.Lock()
+	defer transcript.mutex.Unlock()
+	_, err = transcript.file.Write(append(line, '\n'))
+	return err
+}
+
+func (transcript *Transcript) Close() error {
+	return transcript.file.Close()
+}
+
+// Returns the entry recording the response of the agent at address to a LieQuery, or the error
+// querying it failed with.
+func NewResponseEntry(address string, lie_response *LieResponse, err error) TranscriptEntry {
+	response := &TranscriptResponse{Address: address}
+	if err != nil {
+		response.Error = err.Error()
+	} else if response.LieResponse, err = protojson.Marshal(lie_response); err != nil {
+		response.Error = err.Error()
+	}
+	return TranscriptEntry{Type: RESPONSE_ENTRY, Response: response}
+}
+
+// Reads every entry of the transcript at path.
+func ReadTranscript(path string) ([]TranscriptEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var entries []TranscriptEntry
+	scanner := bufio.NewScanner(file)
+	// A response of a proxy agent may carry the values of thousands of agents.
+	scanner.Buffer(nil, 64*1024*1024)
+	for line_number := 1; scanner.Scan(); line_number++ {
+		var entry TranscriptEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("line %d of %s: %w", line_number, path, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Splits entries into the sessions they were recorded in, each of which starts with its session entry.
+// Entries recorded before the first session entry are dropped.
+func TranscriptSessions(entries []TranscriptEntry) [][]TranscriptEntry {
+	var sessions [][]TranscriptEntry
+	for _, entry := range entries {
+		if entry.Type == SESSION_ENTRY {
+			sessions = append(sessions, nil)
+		}
+		if len(sessions) != 0 {
+			sessions[len(sessions)-1] = append(sessions[len(sessions)-1], entry)
+		}
+	}
+	return sessions
+}
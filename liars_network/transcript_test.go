@@ -0,0 +1,52 @@
+package liars_network
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestTranscript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	// Every session is appended to the same transcript.
+	for seed := int64(1); seed <= 2; seed++ {
+		transcript, err := OpenTranscript(path)
+		if err != nil {
+			t.Fatalf("Failed to open the transcript: %s", err)
+		}
+		entries := []TranscriptEntry{
+			{Type: SESSION_ENTRY, Session: &TranscriptSession{Seed: seed, Mode: "standard"}},
+			{Type: COMMAND_ENTRY, Command: "play"},
+			NewResponseEntry("localhost:9000", &LieResponse{AgentValue: 7}, nil),
+			NewResponseEntry("localhost:9001", nil, errors.New("unreachable")),
+		}
+		for _, entry := range entries {
+			if err := transcript.Record(entry); err != nil {
+				t.Fatalf("Failed to record %v: %s", entry, err)
+			}
+		}
+		transcript.Close()
+	}
+
+	entries, err := ReadTranscript(path)
+	if err != nil {
+		t.Fatalf("Failed to read the transcript: %s", err)
+	}
+	sessions := TranscriptSessions(entries)
+	if len(sessions) != 2 || len(sessions[0]) != 4 || sessions[1][0].Session.Seed != 2 {
+		t.Fatalf("Expected 2 sessions of 4 entries but read %v", sessions)
+	}
+	if entries[0].Time.IsZero() {
+		t.Errorf("The entries should be timestamped.")
+	}
+	response := sessions[0][2].Response
+	lie_response := new(LieResponse)
+	if err := protojson.Unmarshal(response.LieResponse, lie_response); err != nil || lie_response.AgentValue != 7 {
+		t.Errorf("Expected the response to record the value 7, but recorded %s", response.LieResponse)
+	}
+	if sessions[0][3].Response.Error != "unreachable" {
+		t.Errorf("Expected the response to record the error, but recorded %v", sessions[0][3].Response)
+	}
+}
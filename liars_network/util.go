@@ -1,16 +1,32 @@
 package liars_network
 
 import (
-	"fmt"
+	"errors"
+	"io"
 	"math"
-	"strconv"
-	"strings"
+	"os"
 )
 
+// Where the messages of the package are written, including whatever agent processes print.
+var output io.Writer = os.Stdout
+
+// Redirects the messages of the package to writer. Must be called before any agent is launched.
+func SetOutput(writer io.Writer) {
+	output = writer
+}
+
 // Identifies the element of the desired frequency from the input slice. This number
 // needs to unique. If there are multiple elements of the same frequency in the slice,
 // then it fails to find such element.
 func FindNetworkValue(elements []int32, desired_frequency int) (int32, bool) {
+	return FindNetworkValueWithAbstentions(elements, desired_frequency, 0)
+}
+
+// Same as FindNetworkValue, except that abstentions_num agents did not respond. As any of them
+// could be honest, the frequency of the network value in the slice is anywhere between
+// desired_frequency - abstentions_num and desired_frequency. If multiple elements have a frequency
+// within that range, then it fails to find such element.
+func FindNetworkValueWithAbstentions(elements []int32, desired_frequency int, abstentions_num int) (int32, bool) {
 	element_to_frequency_map := map[int32]int{}
 	var network_value int32
 	var same_frequency_element_num int32
@@ -18,7 +34,7 @@ func FindNetworkValue(elements []int32, desired_frequency int) (int32, bool) {
 		element_to_frequency_map[element]++
 	}
 	for element, frequency := range element_to_frequency_map {
-		if frequency == desired_frequency {
+		if frequency <= desired_frequency && frequency >= desired_frequency-abstentions_num {
 			network_value = element
 			same_frequency_element_num++
 		}
@@ -29,178 +45,284 @@ func FindNetworkValue(elements []int32, desired_frequency int) (int32, bool) {
 	return network_value, true
 }
 
-// Sanity checks for kill command and returns the relevant flags
-func CheckKillCommand(kill_command string) (int, bool) {
-	// Disregards the first word "kill"
-	key_value := strings.Split(kill_command, " ")[1:]
-	if len(key_value) != 2 || key_value[0] != "--id" {
-		fmt.Println("Please enter the kill command following the convention of:\n" +
-			"kiill --id id")
-		return math.MinInt, false
-	}
-	// Makes sure id is within the range of [1, 65535]. ParseInt, with bitSize 16 checks the
-	// upper bound. More can be found on tinyurl.com/yckj2twn.
-	if id, err := strconv.ParseInt(key_value[1], 10, 64); err != nil {
-		fmt.Println("Failed to parse id: ", err)
-	} else {
-		if id < 1 || id > 65535 {
-			fmt.Println("Please enter a id in the range of [1, 65535].")
-			return math.MinInt, false
+// Infers the network value from a partial sample of the responses in the network. As the
+// sampled agents are picked at random, the exact number of honest agents among them is
+// unknown, so the liar ratio is used as a prior to estimate it. The element whose frequency
+// is the closest to that estimate is picked. If multiple elements are equally close, then
+// it fails to find such element.
+func FindNetworkValueFromSample(elements []int32, liar_ratio float64) (int32, bool) {
+	if len(elements) == 0 {
+		return 0, false
+	}
+	expected_frequency := len(elements) - int(liar_ratio*float64(len(elements)))
+	element_to_frequency_map := map[int32]int{}
+	for _, element := range elements {
+		element_to_frequency_map[element]++
+	}
+	var network_value int32
+	closest_distance := math.MaxInt
+	var same_distance_element_num int32
+	for element, frequency := range element_to_frequency_map {
+		distance := frequency - expected_frequency
+		if distance < 0 {
+			distance = -distance
 		}
-		return int(id), true
-	}
-	return math.MinInt, false
-}
-
-func CheckPlayExpertCommand(playexpert_command string, running_agents_num int64) map[string]float64 {
-	return_map := make(map[string](float64))
-	// Disregard the first word "playexpert"
-	flag_list := strings.Split(playexpert_command, " ")[1:]
-
-	// The flag list for playexpert command should contain the following elements. The key-value
-	// pair can be in a different order. The length of the list thus needs to be 4.
-	// [--num-agents number --liar-ratio ratio]
-	if len(flag_list) != 4 {
-		return nil
-	}
-	for i, element := range flag_list {
-		switch element {
-		case "--num-agents":
-			if (i + 1) >= len(flag_list) {
-				return nil
-			}
-			num_agents, valid := CheckNumAgentsFlag(flag_list[i+1], running_agents_num)
-			if !valid {
-				return nil
-			}
-			return_map["num_agents"] = num_agents
-
-		case "--liar-ratio":
-			if (i + 1) >= len(flag_list) {
-				return nil
-			}
-			liar_ratio, valid := CheckLiarRatioFlag(flag_list[i+1])
-			if !valid {
-				return nil
-			}
-			return_map["liar_ratio"] = liar_ratio
-		default:
-			continue
+		if distance < closest_distance {
+			closest_distance = distance
+			network_value = element
+			same_distance_element_num = 1
+		} else if distance == closest_distance {
+			same_distance_element_num++
 		}
 	}
-	// Only if both two flags are found does this function return the map; otherwise,
-	// that means there are some unidentified flags, thus causing an incomplete map.
-	if len(return_map) == 2 {
-		return return_map
+	if same_distance_element_num != 1 {
+		return 0, false
 	}
-	return nil
+	return network_value, true
 }
 
-// Sanity checks for start or extend command and returns the relevant flags
-func CheckStartOrExtendCommand(start_command string) map[string]float64 {
-	return_map := make(map[string]float64)
-	// Disregards the first word "start" or "extend"
-	flag_list := strings.Split(start_command, " ")[1:]
-
-	// The flag list for start/extend command should contain the following elemnts. The key-value
-	// pair can be in a different order. The length of the list thus needs to be 8.
-	// [--value, v, --max-value, max, --num-agents, number, --liar-ratio, ratio]
-	if len(flag_list) != 8 {
-		return nil
-	}
-	for i, element := range flag_list {
-		switch element {
-		case "--value":
-			if (i + 1) >= len(flag_list) {
-				return nil
-			}
-			if value, err := strconv.ParseInt(flag_list[i+1], 10, 64); err == nil {
-				return_map["value"] = float64(value)
-			} else {
-				fmt.Println("Error when parsing value: ", err)
-				return nil
-			}
-		case "--max-value":
-			if (i + 1) >= len(flag_list) {
-				return nil
-			}
-			if max_value, err := strconv.ParseInt(flag_list[i+1], 10, 64); err == nil {
-				if max_value < 1 {
-					fmt.Println("max_value must be an integer >= 1")
-					return nil
-				}
-				return_map["max_value"] = float64(max_value)
-			} else {
-				fmt.Println("Error when parsing max_value: ", err)
-				return nil
-			}
-		case "--num-agents":
-			if (i + 1) >= len(flag_list) {
-				return nil
-			}
-			num_agents, valid := CheckNumAgentsFlag(flag_list[i+1], math.MaxInt64)
-			if !valid {
-				return nil
-			}
-			return_map["num_agents"] = num_agents
-
-		case "--liar-ratio":
-			if (i + 1) >= len(flag_list) {
-				return nil
-			}
-			liar_ratio, valid := CheckLiarRatioFlag(flag_list[i+1])
-			if !valid {
-				return nil
-			}
-			return_map["liar_ratio"] = liar_ratio
-
-		default:
-			continue
-		}
+// The max number of agents, which is the number of ports there are. More can be found on
+// tinyurl.com/yckj2twn.
+const MAX_AGENTS_NUM = 65535
+
+// start --value v --max-value max --num-agents number --liar-ratio ratio [--strategy name]
+// [--coalition name] [--tamper p]
+type StartCommand struct {
+	NetworkValue int32
+	MaxValue     int32
+	// The number of agents to launch.
+	AgentsNum int
+	LiarRatio float64
+	// Defaults to CONSTANT_LIE.
+	Strategy StrategyType
+	// Defaults to NO_COALITION.
+	Coalition CoalitionType
+	// The probability with which liars tamper with every value they relay as proxy agents.
+	TamperProbability float64
+}
+
+// extend takes the same flags as start, but launches the agents alongside the running ones.
+type ExtendCommand StartCommand
+
+// The --decider and --threshold flags shared by play and playexpert.
+type DeciderFlags struct {
+	// Defaults to EXACT_DECIDER.
+	Decider DeciderType
+	// Only applies to SUPERMAJORITY_DECIDER. Defaults to DEFAULT_SUPERMAJORITY_THRESHOLD.
+	Threshold float64
+}
+
+// play [--decider name] [--threshold t]
+type PlayCommand struct {
+	DeciderFlags
+}
+
+// playexpert --num-agents number --liar-ratio ratio [--decider name] [--threshold t] [--hops h] [--verify k]
+type PlayExpertCommand struct {
+	// The number of agents sampled, including the proxy agent.
+	AgentsNum int
+	LiarRatio float64
+	DeciderFlags
+	// The proxy agent gossips for at most Hops hops through its neighbors, or contacts the other
+	// sampled agents directly if 0.
+	Hops int
+	// The number of agents spot-checked against the report of the proxy agent, if any.
+	VerifiedAgentsNum int
+}
+
+// topology --shape name [--degree k] [--rewire p]
+type TopologyCommand struct {
+	Shape TopologyType
+	// Defaults to 2.
+	Degree int
+	// Only applies to SMALL_WORLD_TOPOLOGY. Defaults to DEFAULT_REWIRE_PROBABILITY.
+	RewireProbability float64
+}
+
+// kill --id id
+type KillCommand struct {
+	// The host:port address of the agent, or its bare port number.
+	Id string
+}
+
+// replay --file path [--session n]
+type ReplayCommand struct {
+	// The transcript to replay.
+	File string
+	// The session of the transcript to replay, counting from 1. Defaults to 0, which is the last one.
+	Session int
+}
+
+// simulate --trials n --num-agents number --liar-ratio ratio --max-value max [--value v] [--strategy name]
+// [--coalition name] [--decider name] [--threshold t]
+type SimulateCommand struct {
+	TrialsNum int
+	// NetworkValue defaults to 1.
+	SimulationParameters
+}
+
+// sweep --trials n --num-agents number --liar-ratios grid --max-values grid [--value v] [--strategy name]
+// [--coalition name] [--decider name] [--threshold t] [--format csv|json] [--file path]
+type SweepCommand struct {
+	TrialsNum int
+	// Each of LiarRatio and MaxValue is swept over its grid, the rest of the parameters being the same.
+	// NetworkValue defaults to 1.
+	SimulationParameters
+	LiarRatios []float64
+	MaxValues  []int32
+	// Defaults to CSV_FORMAT.
+	Format TableFormat
+	// Where the table is written. Defaults to "", which writes it along with the other messages.
+	File string
+}
+
+func (start_command *StartCommand) flags() []FlagSpec {
+	return []FlagSpec{
+		{Name: "value", Parse: integerFlag(&start_command.NetworkValue, math.MinInt32, math.MaxInt32), Required: true},
+		{Name: "max-value", Parse: integerFlag(&start_command.MaxValue, 1, math.MaxInt32), Required: true},
+		{Name: "num-agents", Parse: integerFlag(&start_command.AgentsNum, 1, MAX_AGENTS_NUM), Required: true},
+		{Name: "liar-ratio", Parse: numberFlag(&start_command.LiarRatio, 0, 1, false), Required: true},
+		{Name: "strategy", Parse: namedFlag(&start_command.Strategy, ParseStrategyType, "constant, random, collude or flip")},
+		{Name: "coalition", Parse: namedFlag(&start_command.Coalition, ParseCoalitionType, "shared or split")},
+		{Name: "tamper", Parse: numberFlag(&start_command.TamperProbability, 0, 1, false)},
 	}
-	// Only if all four flags are found does this function return the map; otherwise,
-	// that means there are some unidentified flags, thus causing an incomplete map.
-	if len(return_map) == 4 {
-		if return_map["max_value"] == 1 && return_map["value"] == return_map["max_value"] {
-			fmt.Println("network_value and max_value cannot both be equal to 1 " +
-				"because fake arbitrary value x needs to be 1 <= x <= max_value and" +
-				"x != network_value")
-			return nil
-		}
-		return return_map
+}
+
+func (start_command *StartCommand) validate() error {
+	if start_command.MaxValue == 1 && start_command.NetworkValue == 1 {
+		return errors.New("network_value and max_value cannot both be equal to 1 " +
+			"because fake arbitrary value x needs to be 1 <= x <= max_value and x != network_value")
 	}
 	return nil
 }
 
-func CheckLiarRatioFlag(ratio string) (float64, bool) {
-	liar_ratio, err := strconv.ParseFloat(ratio, 64)
-	if err == nil {
-		if liar_ratio < 0 || liar_ratio > 1 {
-			fmt.Println("liar_ratio must be >= 0 and <= 1")
-			return math.MaxFloat64, false
-		}
-		return liar_ratio, true
+func (decider_flags *DeciderFlags) flags() []FlagSpec {
+	return []FlagSpec{
+		{Name: "decider", Parse: namedFlag(&decider_flags.Decider, ParseDeciderType, "exact, plurality, supermajority or bayesian")},
+		{Name: "threshold", Parse: numberFlag(&decider_flags.Threshold, 0, 1, true)},
 	}
-	fmt.Println("Error when parsing liar_ratio: ", err)
-	return math.MaxFloat64, false
 }
 
-func CheckNumAgentsFlag(num_agents_str string, running_agents_num int64) (float64, bool) {
-	// Parsing it to because that is the max of ports number
-	// More can be found tinyurl.com/yckj2twn.
-	num_agents, err := strconv.ParseInt(num_agents_str, 10, 64)
-	if err == nil {
-		if num_agents > 65535 {
-			fmt.Println("There can be 65535 agents at most.")
-			return math.MaxFloat64, false
-		}
-		if num_agents <= 0 || num_agents > running_agents_num {
-			fmt.Println("num_agents must be an integer >= 1.",
-				"For playexpert command, num_agents also needs to be  <= the number of runing agents.")
-			return math.MaxFloat64, false
+func ParseStartCommand(command string) (*StartCommand, error) {
+	start_command := new(StartCommand)
+	if err := ParseCommand(command, start_command.flags()); err != nil {
+		return nil, err
+	}
+	return start_command, start_command.validate()
+}
+
+func ParseExtendCommand(command string) (*ExtendCommand, error) {
+	start_command, err := ParseStartCommand(command)
+	if err != nil {
+		return nil, err
+	}
+	return (*ExtendCommand)(start_command), nil
+}
+
+func ParsePlayCommand(command string) (*PlayCommand, error) {
+	play_command := &PlayCommand{DeciderFlags{Threshold: DEFAULT_SUPERMAJORITY_THRESHOLD}}
+	if err := ParseCommand(command, play_command.flags()); err != nil {
+		return nil, err
+	}
+	return play_command, nil
+}
+
+// Parses a playexpert command, which cannot sample more than running_agents_num agents.
+func ParsePlayExpertCommand(command string, running_agents_num int) (*PlayExpertCommand, error) {
+	playexpert_command := &PlayExpertCommand{DeciderFlags: DeciderFlags{Threshold: DEFAULT_SUPERMAJORITY_THRESHOLD}}
+	max_agents_num := running_agents_num
+	if max_agents_num > MAX_AGENTS_NUM {
+		max_agents_num = MAX_AGENTS_NUM
+	}
+	flag_specs := append([]FlagSpec{
+		{Name: "num-agents", Parse: integerFlag(&playexpert_command.AgentsNum, 1, int64(max_agents_num)), Required: true},
+		{Name: "liar-ratio", Parse: numberFlag(&playexpert_command.LiarRatio, 0, 1, false), Required: true},
+		{Name: "hops", Parse: integerFlag(&playexpert_command.Hops, 1, math.MaxInt32)},
+		{Name: "verify", Parse: integerFlag(&playexpert_command.VerifiedAgentsNum, 1, math.MaxInt32)},
+	}, playexpert_command.DeciderFlags.flags()...)
+	if err := ParseCommand(command, flag_specs); err != nil {
+		return nil, err
+	}
+	return playexpert_command, nil
+}
+
+// agents_num is the number of running agents, which every agent needs fewer neighbors than.
+func ParseTopologyCommand(command string, agents_num int) (*TopologyCommand, error) {
+	topology_command := &TopologyCommand{Degree: 2, RewireProbability: DEFAULT_REWIRE_PROBABILITY}
+	flag_specs := []FlagSpec{
+		{Name: "shape", Parse: namedFlag(&topology_command.Shape, ParseTopologyType, "ring, regular or smallworld"), Required: true},
+		{Name: "degree", Parse: integerFlag(&topology_command.Degree, 2, int64(agents_num-1))},
+		{Name: "rewire", Parse: numberFlag(&topology_command.RewireProbability, 0, 1, false)},
+	}
+	if err := ParseCommand(command, flag_specs); err != nil {
+		return nil, err
+	}
+	return topology_command, nil
+}
+
+func ParseReplayCommand(command string) (*ReplayCommand, error) {
+	replay_command := new(ReplayCommand)
+	flag_specs := []FlagSpec{
+		{Name: "file", Parse: stringFlag(&replay_command.File), Required: true},
+		{Name: "session", Parse: integerFlag(&replay_command.Session, 1, math.MaxInt32)},
+	}
+	if err := ParseCommand(command, flag_specs); err != nil {
+		return nil, err
+	}
+	return replay_command, nil
+}
+
+func ParseSimulateCommand(command string) (*SimulateCommand, error) {
+	simulate_command := &SimulateCommand{SimulationParameters: SimulationParameters{NetworkValue: 1,
+		DeciderFlags: DeciderFlags{Threshold: DEFAULT_SUPERMAJORITY_THRESHOLD}}}
+	parameters := &simulate_command.SimulationParameters
+	flag_specs := append([]FlagSpec{
+		{Name: "trials", Parse: integerFlag(&simulate_command.TrialsNum, 1, MAX_TRIALS_NUM), Required: true},
+		{Name: "num-agents", Parse: integerFlag(&parameters.AgentsNum, 1, MAX_AGENTS_NUM), Required: true},
+		{Name: "liar-ratio", Parse: numberFlag(&parameters.LiarRatio, 0, 1, false), Required: true},
+		{Name: "max-value", Parse: integerFlag(&parameters.MaxValue, 1, math.MaxInt32), Required: true},
+		{Name: "value", Parse: integerFlag(&parameters.NetworkValue, math.MinInt32, math.MaxInt32)},
+		{Name: "strategy", Parse: namedFlag(&parameters.Strategy, ParseSimulatedStrategyType, "constant, random or collude")},
+		{Name: "coalition", Parse: namedFlag(&parameters.Coalition, ParseCoalitionType, "shared or split")},
+	}, parameters.DeciderFlags.flags()...)
+	if err := ParseCommand(command, flag_specs); err != nil {
+		return nil, err
+	}
+	start_command := StartCommand{NetworkValue: parameters.NetworkValue, MaxValue: parameters.MaxValue}
+	return simulate_command, start_command.validate()
+}
+
+func ParseSweepCommand(command string) (*SweepCommand, error) {
+	sweep_command := &SweepCommand{SimulationParameters: SimulationParameters{NetworkValue: 1,
+		DeciderFlags: DeciderFlags{Threshold: DEFAULT_SUPERMAJORITY_THRESHOLD}}}
+	parameters := &sweep_command.SimulationParameters
+	flag_specs := append([]FlagSpec{
+		{Name: "trials", Parse: integerFlag(&sweep_command.TrialsNum, 1, MAX_TRIALS_NUM), Required: true},
+		{Name: "num-agents", Parse: integerFlag(&parameters.AgentsNum, 1, MAX_AGENTS_NUM), Required: true},
+		{Name: "liar-ratios", Parse: numberGridFlag(&sweep_command.LiarRatios, 0, 1), Required: true},
+		{Name: "max-values", Parse: integerGridFlag(&sweep_command.MaxValues, 1, math.MaxInt32), Required: true},
+		{Name: "value", Parse: integerFlag(&parameters.NetworkValue, math.MinInt32, math.MaxInt32)},
+		{Name: "strategy", Parse: namedFlag(&parameters.Strategy, ParseSimulatedStrategyType, "constant, random or collude")},
+		{Name: "coalition", Parse: namedFlag(&parameters.Coalition, ParseCoalitionType, "shared or split")},
+		{Name: "format", Parse: namedFlag(&sweep_command.Format, ParseTableFormat, "csv or json")},
+		{Name: "file", Parse: stringFlag(&sweep_command.File)},
+	}, parameters.DeciderFlags.flags()...)
+	if err := ParseCommand(command, flag_specs); err != nil {
+		return nil, err
+	}
+	for _, max_value := range sweep_command.MaxValues {
+		start_command := StartCommand{NetworkValue: parameters.NetworkValue, MaxValue: max_value}
+		if err := start_command.validate(); err != nil {
+			return sweep_command, err
 		}
-		return float64(num_agents), true
 	}
-	fmt.Println("Error when parsing num_agents: ", err)
-	return math.MaxFloat64, false
+	return sweep_command, nil
+}
+
+func ParseKillCommand(command string) (*KillCommand, error) {
+	kill_command := new(KillCommand)
+	if err := ParseCommand(command, []FlagSpec{{Name: "id", Parse: agentIdFlag(&kill_command.Id), Required: true}}); err != nil {
+		return nil, err
+	}
+	return kill_command, nil
 }
@@ -20,113 +20,254 @@ func TestFindNetworkValue(t *testing.T) {
 	}
 }
 
-func TestCheckStartOrExtendCommand(t *testing.T) {
-	// cannot parse int/float64
-	start_command_1 := "start --value v --max-value max --num-agents number --liar-ratio ratio"
-	if CheckStartOrExtendCommand(start_command_1) != nil {
-		t.Errorf(start_command_1, "should fail CheckStartOrExtendCommand() because the passed in args are parsable.")
+func TestFindNetworkValueWithAbstentions(t *testing.T) {
+	// One of the 3 honest agents abstained.
+	test_elements_array_1 := []int32{1, 1, 2, 3}
+	if desired_element, exists := FindNetworkValueWithAbstentions(test_elements_array_1, 3, 1); !exists || desired_element != 1 {
+		t.Errorf("%v should find 1 as the desired element", test_elements_array_1)
 	}
-	start_command_2 := "start --hello"
-	if CheckStartOrExtendCommand(start_command_2) != nil {
-		t.Errorf(start_command_2, "should fail CheckStartOrExtendCommand() because the command is incomplete.")
+	if _, exists := FindNetworkValueWithAbstentions(test_elements_array_1, 3, 0); exists {
+		t.Errorf("%v should not have any desired element when no agent abstained", test_elements_array_1)
 	}
-	start_command_3 := "start --value 10 --max-value 100 --num-agents 3 --liar-ratio 0.5"
-	successful_map := CheckStartOrExtendCommand(start_command_3)
-	if successful_map == nil {
-		t.Errorf(start_command_3, "should succeed.")
+	// Any of 1, 2 and 3 could be the network value when two agents abstained.
+	if _, exists := FindNetworkValueWithAbstentions(test_elements_array_1, 3, 2); exists {
+		t.Errorf("%v should not have any desired element when two agents abstained", test_elements_array_1)
+	}
+}
+
+func TestFindNetworkValueFromSample(t *testing.T) {
+	test_elements_array_1 := []int32{5, 5, 5, 2, 9}
+	if desired_element, exists := FindNetworkValueFromSample(test_elements_array_1, 0.5); !exists || desired_element != 5 {
+		t.Errorf("%v should find 5 as the desired element", test_elements_array_1)
 	}
-	if len(successful_map) != 4 {
-		t.Errorf("successful_map should always be of size 4")
+	// 3 honest agents are expected in the sample, but only 2 of them were sampled.
+	test_elements_array_2 := []int32{5, 5, 2, 9}
+	if desired_element, exists := FindNetworkValueFromSample(test_elements_array_2, 0.25); !exists || desired_element != 5 {
+		t.Errorf("%v should find 5 as the desired element", test_elements_array_2)
 	}
-	if successful_map["value"] != 10 {
-		t.Errorf("Did not parse network_value correctly.")
+	test_elements_array_3 := []int32{5, 5, 2, 2}
+	if _, exists := FindNetworkValueFromSample(test_elements_array_3, 0.5); exists {
+		t.Errorf("%v should not have any desired element because both 5 and 2 are equally close", test_elements_array_3)
+	}
+	if _, exists := FindNetworkValueFromSample([]int32{}, 0.5); exists {
+		t.Errorf("An empty sample should not have any desired element")
+	}
+}
+
+func TestParseStartCommand(t *testing.T) {
+	// cannot parse int/float64
+	start_command_1 := "start --value v --max-value max --num-agents number --liar-ratio ratio"
+	if _, err := ParseStartCommand(start_command_1); err == nil {
+		t.Errorf("%s should fail ParseStartCommand() because the passed in args are not parsable.", start_command_1)
 	}
-	if successful_map["max_value"] != 100 {
-		t.Errorf("Did not parse max_value correctly.")
+	start_command_2 := "start --hello"
+	if _, err := ParseStartCommand(start_command_2); err == nil {
+		t.Errorf("%s should fail ParseStartCommand() because the command is incomplete.", start_command_2)
 	}
-	if successful_map["num_agents"] != 3 {
-		t.Errorf("Did not parse num_agents correctly.")
+	start_command_3 := "start --value 10 --max-value 100 --num-agents 3 --liar-ratio 0.5"
+	start_command, err := ParseStartCommand(start_command_3)
+	if err != nil {
+		t.Fatalf("%s should succeed, but failed with %s", start_command_3, err)
 	}
-	if successful_map["liar_ratio"] != 0.5 {
-		t.Errorf("Did not parse liar_ratio correctly.")
+	expected_command := StartCommand{NetworkValue: 10, MaxValue: 100, AgentsNum: 3, LiarRatio: 0.5}
+	if *start_command != expected_command {
+		t.Errorf("Parsed %+v rather than %+v", *start_command, expected_command)
 	}
 	// wrong flag name passed in
 	start_command_4 := "start --value 10 --name Goo --num-agents 3 --liar-ratio 0.5"
-	if CheckStartOrExtendCommand(start_command_4) != nil {
-		t.Errorf(start_command_4, "should fail CheckStartOrExtendCommand() because the flag passed in is unidentified")
+	if _, err := ParseStartCommand(start_command_4); err == nil {
+		t.Errorf("%s should fail ParseStartCommand() because the flag passed in is unidentified", start_command_4)
 	}
 	// too many agents created
 	start_command_5 := "start --value 10 --max-value 100 --num-agents 65536 --liar-ratio 0.5"
-	if CheckStartOrExtendCommand(start_command_5) != nil {
-		t.Errorf(start_command_5, "should fail CheckStartOrExtendCommand() because num_agents >= (2^16-1)")
+	if _, err := ParseStartCommand(start_command_5); err == nil {
+		t.Errorf("%s should fail ParseStartCommand() because num_agents >= (2^16-1)", start_command_5)
 	}
-	// CheckStartOrExtendCommand() should still work with different ordering of key-pairs
+	// ParseStartCommand() should still work with different ordering of key-pairs
 	start_command_6 := "start --liar-ratio 0.5 --value 10 --max-value 100 --num-agents 3"
-	successful_map = CheckStartOrExtendCommand(start_command_6)
-	if successful_map == nil {
-		t.Errorf(start_command_3, "should succeed.")
+	if start_command, err := ParseStartCommand(start_command_6); err != nil || *start_command != expected_command {
+		t.Errorf("%s should succeed.", start_command_6)
 	}
-	if len(successful_map) != 4 {
-		t.Errorf("successful_map should always be of size 4")
+	start_command_7 := "extend --value 1 --max-value 1 --num-agents 3 --liar-ratio 0.5"
+	if _, err := ParseExtendCommand(start_command_7); err == nil {
+		t.Errorf("%s should fail because no liar can pick a value other than 1.", start_command_7)
 	}
-	if successful_map["value"] != 10 {
-		t.Errorf("Did not parse network_value correctly.")
+}
+
+func TestParseStartCommandWithStrategy(t *testing.T) {
+	start_command_1 := "start --value 10 --max-value 100 --num-agents 3 --liar-ratio 0.5 --strategy random"
+	if start_command, err := ParseStartCommand(start_command_1); err != nil || start_command.Strategy != RANDOM_LIE {
+		t.Errorf("%s should succeed with the random strategy.", start_command_1)
 	}
-	if successful_map["max_value"] != 100 {
-		t.Errorf("Did not parse max_value correctly.")
+	start_command_2 := "start --value 10 --max-value 100 --num-agents 3 --liar-ratio 0.5 --strategy honest"
+	if _, err := ParseStartCommand(start_command_2); err == nil {
+		t.Errorf("%s should fail because honest is an unidentified strategy.", start_command_2)
 	}
-	if successful_map["num_agents"] != 3 {
-		t.Errorf("Did not parse num_agents correctly.")
+	start_command_3 := "start --value 10 --max-value 100 --num-agents 3 --liar-ratio 0.5 --name Goo"
+	if _, err := ParseStartCommand(start_command_3); err == nil {
+		t.Errorf("%s should fail because --name is an unidentified flag.", start_command_3)
 	}
-	if successful_map["liar_ratio"] != 0.5 {
-		t.Errorf("Did not parse liar_ratio correctly.")
+	start_command_4 := "start --value 10 --max-value 100 --num-agents 3 --liar-ratio 0.5 --tamper 0.5"
+	if start_command, err := ParseStartCommand(start_command_4); err != nil || start_command.TamperProbability != 0.5 {
+		t.Errorf("%s should succeed.", start_command_4)
+	}
+	start_command_5 := "start --value 10 --max-value 100 --num-agents 3 --liar-ratio 0.5 --tamper 2"
+	if _, err := ParseStartCommand(start_command_5); err == nil {
+		t.Errorf("%s should fail because tamper is out of range.", start_command_5)
 	}
 }
 
-func TestCheckKillCommand(t *testing.T) {
-	kill_command_1 := "kill --id -1"
-	if _, valid := CheckKillCommand(kill_command_1); valid {
-		t.Errorf(kill_command_1, "has an id out of range of [1, 65535].")
+func TestParseKillCommand(t *testing.T) {
+	for _, kill_command := range []string{"kill --id -1", "kill --id localhost", "kill --id localhost:0", "kill --id 10.0.0.2:70000"} {
+		if _, err := ParseKillCommand(kill_command); err == nil {
+			t.Errorf("%s has an id which is neither an address nor a port in [1, 65535].", kill_command)
+		}
 	}
 	kill_command_2 := "kill --comany 123"
-	if _, valid := CheckKillCommand(kill_command_2); valid {
-		t.Errorf(kill_command_2, "has a unrecognized flag comany.")
+	if _, err := ParseKillCommand(kill_command_2); err == nil {
+		t.Errorf("%s has a unrecognized flag comany.", kill_command_2)
 	}
 	kill_command_3 := "kill --id 123"
-	if id, valid := CheckKillCommand(kill_command_3); !valid || id != 123 {
-		t.Errorf(kill_command_3, "is a valid kill command.")
+	if kill_command, err := ParseKillCommand(kill_command_3); err != nil || kill_command.Id != "123" {
+		t.Errorf("%s is a valid kill command.", kill_command_3)
+	}
+	if kill_command, err := ParseKillCommand("kill --id [::1]:0123"); err != nil || kill_command.Id != "[::1]:123" {
+		t.Errorf("An address should be parsed in the form agents are reached through, got %+v, %v", kill_command, err)
 	}
 }
 
-func TestPlayExpertCommand(t *testing.T) {
+func TestParsePlayExpertCommand(t *testing.T) {
 	playexpert_command_1 := "playexpert --num-agents 3 --liar-ratio 0.5"
-	successful_map := CheckPlayExpertCommand(playexpert_command_1, math.MaxInt64)
-	if successful_map == nil {
-		t.Errorf(playexpert_command_1, "should succeed.")
-	}
-	if len(successful_map) != 2 {
-		t.Errorf("successful_map should always be of size 2.")
+	playexpert_command, err := ParsePlayExpertCommand(playexpert_command_1, math.MaxInt)
+	if err != nil {
+		t.Fatalf("%s should succeed, but failed with %s", playexpert_command_1, err)
 	}
-	if successful_map["num_agents"] != 3 {
-		t.Errorf("Did not parse num_agents correctly.")
+	if playexpert_command.AgentsNum != 3 || playexpert_command.LiarRatio != 0.5 {
+		t.Errorf("Did not parse num_agents or liar_ratio correctly.")
 	}
-	if successful_map["liar_ratio"] != 0.5 {
-		t.Errorf("Did not parse liar_ratio correctly.")
+	if playexpert_command.Decider != EXACT_DECIDER || playexpert_command.Threshold != DEFAULT_SUPERMAJORITY_THRESHOLD ||
+		playexpert_command.Hops != 0 || playexpert_command.VerifiedAgentsNum != 0 {
+		t.Errorf("Did not default the optional flags correctly.")
 	}
 
 	playexpert_command_2 := "playexpert --num-agents --liar-ratio 3 0.5"
-	if CheckPlayExpertCommand(playexpert_command_2, math.MaxInt64) != nil {
-		t.Errorf(playexpert_command_2, "should fail because a flag value is not followed immediately after a flag.")
-
+	if _, err := ParsePlayExpertCommand(playexpert_command_2, math.MaxInt); err == nil {
+		t.Errorf("%s should fail because a flag value is not followed immediately after a flag.", playexpert_command_2)
 	}
 
 	playexpert_command_3 := "playexpert --network cosmos --liar-ratio 0.5"
-	if CheckPlayExpertCommand(playexpert_command_3, math.MaxInt64) != nil {
-		t.Errorf(playexpert_command_2, "should fail because --network is an unidentified flag.")
+	if _, err := ParsePlayExpertCommand(playexpert_command_3, math.MaxInt); err == nil {
+		t.Errorf("%s should fail because --network is an unidentified flag.", playexpert_command_3)
 	}
 
 	playexpert_command_4 := "playexpert --num-agents 3 --liar-ratio 0.5"
-	if CheckPlayExpertCommand(playexpert_command_4, 1) != nil {
-		t.Errorf(playexpert_command_2, "should fail because num_agents should be less than the number of running agents.")
+	if _, err := ParsePlayExpertCommand(playexpert_command_4, 1); err == nil {
+		t.Errorf("%s should fail because num_agents should be less than the number of running agents.", playexpert_command_4)
+	}
+
+	playexpert_command_5 := "playexpert --num-agents 3 --decider bayesian --liar-ratio 0.5 --hops 2 --verify 2"
+	if playexpert_command, err := ParsePlayExpertCommand(playexpert_command_5, math.MaxInt); err != nil ||
+		playexpert_command.Decider != BAYESIAN_DECIDER || playexpert_command.Hops != 2 || playexpert_command.VerifiedAgentsNum != 2 {
+		t.Errorf("%s should succeed.", playexpert_command_5)
+	}
+	if _, err := ParsePlayExpertCommand("playexpert --num-agents 3 --liar-ratio 0.5 --hops 0", math.MaxInt); err == nil {
+		t.Errorf("playexpert should fail because hops must be >= 1.")
+	}
+}
+
+func TestParsePlayCommand(t *testing.T) {
+	if play_command, err := ParsePlayCommand("play"); err != nil || play_command.Decider != EXACT_DECIDER {
+		t.Errorf("play should succeed without any flag.")
+	}
+	play_command_1 := "play --decider supermajority --threshold 0.8"
+	if play_command, err := ParsePlayCommand(play_command_1); err != nil || play_command.Decider != SUPERMAJORITY_DECIDER ||
+		play_command.Threshold != 0.8 {
+		t.Errorf("Did not parse decider or threshold correctly.")
+	}
+	play_command_2 := "play --decider majority"
+	if _, err := ParsePlayCommand(play_command_2); err == nil {
+		t.Errorf("%s should fail because majority is an unidentified decider.", play_command_2)
+	}
+	play_command_3 := "play --threshold 1.5"
+	if _, err := ParsePlayCommand(play_command_3); err == nil {
+		t.Errorf("%s should fail because threshold is out of range.", play_command_3)
+	}
+	play_command_4 := "play --network cosmos"
+	if _, err := ParsePlayCommand(play_command_4); err == nil {
+		t.Errorf("%s should fail because --network is an unidentified flag.", play_command_4)
+	}
+}
+
+func TestParseTopologyCommand(t *testing.T) {
+	topology_command_1 := "topology --shape ring"
+	topology_command, err := ParseTopologyCommand(topology_command_1, 10)
+	if err != nil {
+		t.Fatalf("%s should succeed, but failed with %s", topology_command_1, err)
+	}
+	if topology_command.Shape != RING_TOPOLOGY || topology_command.Degree != 2 {
+		t.Errorf("Did not parse shape or default degree correctly.")
+	}
+	topology_command_2 := "topology --rewire 0.3 --shape smallworld --degree 4"
+	if topology_command, err := ParseTopologyCommand(topology_command_2, 10); err != nil || topology_command.Degree != 4 ||
+		topology_command.RewireProbability != 0.3 {
+		t.Errorf("%s should succeed.", topology_command_2)
+	}
+	topology_command_3 := "topology --degree 4"
+	if _, err := ParseTopologyCommand(topology_command_3, 10); err == nil {
+		t.Errorf("%s should fail because --shape is missing.", topology_command_3)
+	}
+	topology_command_4 := "topology --shape star"
+	if _, err := ParseTopologyCommand(topology_command_4, 10); err == nil {
+		t.Errorf("%s should fail because star is an unidentified shape.", topology_command_4)
+	}
+	for _, degree := range []string{"-2147483648", "0", "1", "10", "2147483647"} {
+		if _, err := ParseTopologyCommand("topology --shape regular --degree "+degree, 10); err == nil {
+			t.Errorf("A degree of %s should fail because it is not in [2, 9].", degree)
+		}
+	}
+}
+
+func TestParseSimulateCommand(t *testing.T) {
+	simulate_command, err := ParseSimulateCommand("simulate --trials 100 --num-agents 10 --liar-ratio 0.3 --max-value 5 --strategy random")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	if simulate_command.TrialsNum != 100 || simulate_command.AgentsNum != 10 || simulate_command.NetworkValue != 1 ||
+		simulate_command.Strategy != RANDOM_LIE || simulate_command.Threshold != DEFAULT_SUPERMAJORITY_THRESHOLD {
+		t.Errorf("Did not parse the flags or keep the defaults correctly: %+v", simulate_command)
+	}
+	invalid_commands := []string{
+		"simulate --num-agents 10 --liar-ratio 0.3 --max-value 5",
+		"simulate --trials 0 --num-agents 10 --liar-ratio 0.3 --max-value 5",
+		"simulate --trials 10 --num-agents 10 --liar-ratio 0.3 --max-value 1",
+		"simulate --trials 10 --num-agents 10 --liar-ratio 0.3 --max-value 5 --strategy flip",
+	}
+	for _, command := range invalid_commands {
+		if _, err := ParseSimulateCommand(command); err == nil {
+			t.Errorf("%q should be invalid", command)
+		}
+	}
+}
+
+func TestParseSweepCommand(t *testing.T) {
+	sweep_command, err := ParseSweepCommand("sweep --trials 10 --num-agents 9 --liar-ratios 0:0.4:0.2 --max-values 2,5 --format json")
+	if err != nil {
+		t.Fatalf("Failed to parse: %s", err)
+	}
+	if len(sweep_command.LiarRatios) != 3 || len(sweep_command.MaxValues) != 2 || sweep_command.MaxValues[1] != 5 ||
+		sweep_command.Format != JSON_FORMAT || sweep_command.File != "" {
+		t.Errorf("Did not parse the flags or keep the defaults correctly: %+v", sweep_command)
+	}
+	invalid_commands := []string{
+		"sweep --trials 10 --num-agents 9 --liar-ratios 0:2:0.5 --max-values 2",
+		"sweep --trials 10 --num-agents 9 --liar-ratios 0.1 --max-values 2.5",
+		"sweep --trials 10 --num-agents 9 --liar-ratios 0.1 --max-values 1,2",
+		"sweep --trials 10 --num-agents 9 --liar-ratios 0.1 --max-values 2 --format xml",
+		"sweep --trials 10 --num-agents 9 --liar-ratios 0.1 --max-values 2 --strategy flip",
+	}
+	for _, command := range invalid_commands {
+		if _, err := ParseSweepCommand(command); err == nil {
+			t.Errorf("%q should be invalid", command)
+		}
 	}
 }